@@ -0,0 +1,290 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+)
+
+// initialisms are the parts of names, which are written in upper case in Go.
+var initialisms = map[string]string{
+	"Api": "API", "Dc": "DC", "Html": "HTML", "Http": "HTTP", "Https": "HTTPS", "Id": "ID", "Ids": "IDs",
+	"Ip": "IP", "Json": "JSON", "Sms": "SMS", "Ttl": "TTL", "Url": "URL", "Urls": "URLs",
+}
+
+// reservedNames are the names of methods of generated types, which must not be used as field names.
+var reservedNames = map[string]bool{"ObjectType": true, "MarshalJSON": true, "UnmarshalJSON": true}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// goName converts a TL name in camelCase or snake_case to an exported Go name.
+func goName(name string) string {
+	var result strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		part = upperFirst(part)
+		begin := 0
+		for i, r := range part {
+			if i > begin && unicode.IsUpper(r) {
+				result.WriteString(goWord(part[begin:i]))
+				begin = i
+			}
+		}
+		result.WriteString(goWord(part[begin:]))
+	}
+	return result.String()
+}
+
+func goWord(word string) string {
+	if initialism, ok := initialisms[word]; ok {
+		return initialism
+	}
+	return word
+}
+
+type generator struct {
+	schema       *schema
+	constructors map[string]*combinator
+	buf          bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) comment(lines ...string) {
+	for _, line := range lines {
+		if line != "" {
+			g.printf("// %s\n", line)
+		}
+	}
+}
+
+// goType returns the Go type used for values of the TL type.
+func (g *generator) goType(t *tlType) (string, error) {
+	switch t.name {
+	case "vector":
+		elem, err := g.goType(t.elem)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "double":
+		return "float64", nil
+	case "string":
+		return "string", nil
+	case "int32":
+		return "int32", nil
+	case "int53":
+		return "int64", nil
+	case "int64":
+		return "Int64", nil // serialized as a string
+	case "bytes":
+		return "[]byte", nil // serialized in base64 by both TDLib and encoding/json
+	case "Bool":
+		return "bool", nil
+	}
+	if class, ok := g.schema.classes[t.name]; ok {
+		if class.isAbstract() {
+			return goName(class.name), nil
+		}
+		return "*" + goName(class.constructors[0].name), nil
+	}
+	if c, ok := g.constructors[t.name]; ok {
+		return "*" + goName(c.name), nil
+	}
+	return "", fmt.Errorf("unknown type %s", t)
+}
+
+// needsDecoding returns true if values of the type can't be deserialized by encoding/json directly.
+func (g *generator) needsDecoding(t *tlType) bool {
+	if t.elem != nil {
+		return g.needsDecoding(t.elem)
+	}
+	class, ok := g.schema.classes[t.name]
+	return ok && class.isAbstract()
+}
+
+// decode prints statements deserializing the value of the type from the JSON in src to dst.
+func (g *generator) decode(t *tlType, src string, dst string, depth int) error {
+	if t.elem == nil {
+		g.printf("if %s, err = Unmarshal%s(%s); err != nil {\nreturn err\n}\n", dst, goName(t.name), src)
+		return nil
+	}
+
+	elemType, err := g.goType(t.elem)
+	if err != nil {
+		return err
+	}
+	list := fmt.Sprintf("list%d", depth)
+	index := fmt.Sprintf("i%d", depth)
+	elem := fmt.Sprintf("elem%d", depth)
+	g.printf("var %s []json.RawMessage\nif %s, err = unmarshalList(%s); err != nil {\nreturn err\n}\n", list, list, src)
+	g.printf("%s = make([]%s, len(%s))\n", dst, elemType, list)
+	g.printf("for %s, %s := range %s {\n", index, elem, list)
+	if err := g.decode(t.elem, elem, dst+"["+index+"]", depth+1); err != nil {
+		return err
+	}
+	g.printf("}\n")
+	return nil
+}
+
+func (g *generator) generateClass(class *class) {
+	name := goName(class.name)
+	g.comment(name+" is the TL class "+class.name+".", class.description)
+	g.printf("type %s interface {\nObject\nis%s()\n}\n\n", name, name)
+
+	g.printf("// Unmarshal%s deserializes an object of class %s from JSON. Nil is returned for null.\n", name, class.name)
+	g.printf("func Unmarshal%s(data []byte) (%s, error) {\n", name, name)
+	g.printf("object, err := Unmarshal(data)\nif err != nil || object == nil {\nreturn nil, err\n}\n")
+	g.printf("result, ok := object.(%s)\nif !ok {\n", name)
+	g.printf("return nil, fmt.Errorf(\"tdapi: %%s isn't of class %s\", object.ObjectType())\n}\n", class.name)
+	g.printf("return result, nil\n}\n\n")
+}
+
+func (g *generator) generateCombinator(c *combinator) error {
+	name := goName(c.name)
+	kind := "object"
+	if c.isFunction {
+		kind = "function"
+		g.comment(name+" is the TL function "+c.name+", which returns "+c.result+".", c.description)
+	} else {
+		g.comment(name+" is the TL constructor "+c.name+".", c.description)
+	}
+	g.printf("type %s struct {\n", name)
+	fieldNames := make(map[string]bool)
+	var decodedArgs []*arg
+	for _, arg := range c.args {
+		fieldName := goName(arg.name)
+		if fieldNames[fieldName] || reservedNames[fieldName] {
+			return fmt.Errorf("%s: field name %s is already used", c.name, fieldName)
+		}
+		fieldNames[fieldName] = true
+
+		fieldType, err := g.goType(arg.typ)
+		if err != nil {
+			return fmt.Errorf("%s: %v", c.name, err)
+		}
+		g.comment(arg.description)
+		// zero values are omitted, see the tdapi package documentation
+		g.printf("%s %s `json:\"%s,omitempty\"`\n", fieldName, fieldType, arg.name)
+		if g.needsDecoding(arg.typ) {
+			decodedArgs = append(decodedArgs, arg)
+		}
+	}
+	g.printf("}\n\n")
+
+	g.printf("// ObjectType returns the TL name of the %s.\n", kind)
+	g.printf("func (*%s) ObjectType() string {\nreturn %q\n}\n\n", name, c.name)
+	if c.isFunction {
+		g.printf("func (*%s) isFunction() {}\n\n", name)
+	} else if class := g.schema.classes[c.result]; class.isAbstract() {
+		g.printf("func (*%s) is%s() {}\n\n", name, goName(class.name))
+	}
+
+	g.printf("// MarshalJSON serializes the %s to JSON along with its \"@type\".\n", kind)
+	g.printf("func (o %s) MarshalJSON() ([]byte, error) {\ntype alias %s\n", name, name)
+	g.printf("return json.Marshal(struct {\nTdType string `json:\"@type\"`\nalias\n}{%q, alias(o)})\n}\n\n", c.name)
+
+	if len(decodedArgs) == 0 {
+		return nil
+	}
+	g.printf("// UnmarshalJSON deserializes the %s from JSON.\n", kind)
+	g.printf("func (o *%s) UnmarshalJSON(data []byte) error {\ntype alias %s\nvar raw struct {\n*alias\n", name, name)
+	for _, arg := range decodedArgs {
+		g.printf("%s json.RawMessage `json:\"%s\"`\n", goName(arg.name), arg.name)
+	}
+	g.printf("}\nraw.alias = (*alias)(o)\nif err := json.Unmarshal(data, &raw); err != nil {\nreturn err\n}\n")
+	g.printf("var err error\n")
+	for _, arg := range decodedArgs {
+		g.printf("{\n")
+		if err := g.decode(arg.typ, "raw."+goName(arg.name), "o."+goName(arg.name), 0); err != nil {
+			return fmt.Errorf("%s: %v", c.name, err)
+		}
+		g.printf("}\n")
+	}
+	g.printf("return nil\n}\n\n")
+	return nil
+}
+
+// generate returns the formatted Go source code for the schema.
+func generate(s *schema, packageName string) ([]byte, error) {
+	g := &generator{schema: s, constructors: make(map[string]*combinator)}
+	names := map[string]string{"Object": "", "Function": "", "Int64": ""} // declared in tdapi.go
+	declare := func(tlName string, goName string) error {
+		if other, ok := names[goName]; ok {
+			return fmt.Errorf("both %s and %s are named %s in Go", other, tlName, goName)
+		}
+		names[goName] = tlName
+		return nil
+	}
+	for _, c := range s.constructors {
+		g.constructors[c.name] = c
+		if err := declare(c.name, goName(c.name)); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range s.functions {
+		if err := declare(c.name, goName(c.name)); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range s.classNames {
+		if class := s.classes[name]; class.isAbstract() {
+			if err := declare(name, goName(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	g.printf("// Code generated by tlgen from td_api.tl. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\nimport (\n\"encoding/json\"\n\"fmt\"\n)\n\n", packageName)
+	for _, name := range s.classNames {
+		if class := s.classes[name]; class.isAbstract() {
+			g.generateClass(class)
+		}
+	}
+	for _, c := range s.constructors {
+		if err := g.generateCombinator(c); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range s.functions {
+		if err := g.generateCombinator(c); err != nil {
+			return nil, err
+		}
+	}
+
+	g.printf("// objectTypes contains constructors of all objects by their \"@type\".\n")
+	g.printf("var objectTypes = map[string]func() Object{\n")
+	for _, c := range s.constructors {
+		g.printf("%q: func() Object { return new(%s) },\n", c.name, goName(c.name))
+	}
+	g.printf("}\n\n")
+
+	g.printf("// functionTypes contains constructors of all functions by their \"@type\".\n")
+	g.printf("var functionTypes = map[string]func() Function{\n")
+	for _, c := range s.functions {
+		g.printf("%q: func() Function { return new(%s) },\n", c.name, goName(c.name))
+	}
+	g.printf("}\n")
+
+	source, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v", err)
+	}
+	return source, nil
+}
@@ -0,0 +1,65 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+// Command tlgen generates Go types for objects and functions of the TDLib JSON interface from a TL schema.
+//
+// Usage:
+//
+//	tlgen [-o output.go] [-package name] td_api.tl
+//
+// For every constructor and function a struct is generated, which is serialized to JSON along with its "@type".
+// Classes with more than one constructor are represented by interfaces. Values of the TL type int64 are
+// represented by Int64, which is serialized as a string. Fields with zero values are omitted. The generated code
+// relies on the declarations from tdapi/tdapi.go, which must be present in the same package.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	output := flag.String("o", "", "output file; the standard output is used by default")
+	packageName := flag.String("package", "tdapi", "name of the generated package")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-o output.go] [-package name] td_api.tl\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), *output, *packageName); err != nil {
+		fmt.Fprintf(os.Stderr, "tlgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath string, outputPath string, packageName string) error {
+	file, err := os.Open(schemaPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	s, err := parseSchema(file)
+	if err != nil {
+		return fmt.Errorf("%s: %v", schemaPath, err)
+	}
+
+	source, err := generate(s, packageName)
+	if err != nil {
+		return err
+	}
+	if outputPath == "" {
+		_, err = os.Stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(outputPath, source, 0644)
+}
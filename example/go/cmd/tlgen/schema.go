@@ -0,0 +1,202 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// tlType is a type of a combinator argument.
+type tlType struct {
+	name string  // "vector" for vectors
+	elem *tlType // type of vector elements
+}
+
+func (t *tlType) String() string {
+	if t.elem != nil {
+		return "vector<" + t.elem.String() + ">"
+	}
+	return t.name
+}
+
+type arg struct {
+	name        string
+	typ         *tlType
+	description string
+}
+
+// combinator is a constructor of an object or a function.
+type combinator struct {
+	name        string
+	args        []*arg
+	result      string
+	description string
+	isFunction  bool
+}
+
+// class is a type, which is a result of one or more constructors.
+type class struct {
+	name         string
+	description  string
+	constructors []*combinator
+}
+
+// isAbstract returns true if the class can't be represented by a single Go struct.
+func (c *class) isAbstract() bool {
+	return len(c.constructors) != 1 || upperFirst(c.constructors[0].name) != c.name
+}
+
+type schema struct {
+	classes      map[string]*class
+	classNames   []string // in order of declaration
+	constructors []*combinator
+	functions    []*combinator
+}
+
+// builtinTypes are the types, which are declared in the schema, but are mapped to Go types directly.
+var builtinTypes = map[string]bool{
+	"Double": true, "String": true, "Int32": true, "Int53": true, "Int64": true, "Bytes": true, "Bool": true, "Vector": true,
+}
+
+var docTagRegexp = regexp.MustCompile(`(?:^|\s)@(\w+)\s`)
+
+// parseDocs splits a documentation comment to descriptions by tag.
+func parseDocs(text string) map[string]string {
+	docs := make(map[string]string)
+	matches := docTagRegexp.FindAllStringSubmatchIndex(text, -1)
+	for i, match := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		docs[text[match[2]:match[3]]] = strings.TrimSpace(text[match[1]:end])
+	}
+	return docs
+}
+
+func parseType(s string) (*tlType, error) {
+	if strings.HasPrefix(s, "vector<") {
+		if !strings.HasSuffix(s, ">") {
+			return nil, fmt.Errorf("invalid type %q", s)
+		}
+		elem, err := parseType(s[len("vector<") : len(s)-1])
+		if err != nil {
+			return nil, err
+		}
+		return &tlType{name: "vector", elem: elem}, nil
+	}
+	if s == "" || strings.ContainsAny(s, "<>") {
+		return nil, fmt.Errorf("invalid type %q", s)
+	}
+	return &tlType{name: s}, nil
+}
+
+// parseCombinator parses a declaration like "name arg:type ... = Result;".
+func parseCombinator(line string, docs map[string]string) (*combinator, error) {
+	parts := strings.SplitN(strings.TrimSuffix(line, ";"), "=", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid declaration %q", line)
+	}
+	fields := strings.Fields(parts[0])
+	result := strings.TrimSpace(parts[1])
+	if len(fields) == 0 || result == "" {
+		return nil, fmt.Errorf("invalid declaration %q", line)
+	}
+
+	c := &combinator{name: fields[0], result: result, description: docs["description"]}
+	for _, field := range fields[1:] {
+		nameAndType := strings.SplitN(field, ":", 2)
+		if len(nameAndType) != 2 {
+			return nil, fmt.Errorf("invalid argument %q of %s", field, c.name)
+		}
+		typ, err := parseType(nameAndType[1])
+		if err != nil {
+			return nil, fmt.Errorf("argument %s of %s: %v", nameAndType[0], c.name, err)
+		}
+		description := docs[nameAndType[0]]
+		if nameAndType[0] == "description" {
+			description = docs["param_description"]
+		}
+		c.args = append(c.args, &arg{name: nameAndType[0], typ: typ, description: description})
+	}
+	return c, nil
+}
+
+// parseSchema parses a TL schema in the format of td_api.tl.
+func parseSchema(r io.Reader) (*schema, error) {
+	s := &schema{classes: make(map[string]*class)}
+	getClass := func(name string) *class {
+		if c, ok := s.classes[name]; ok {
+			return c
+		}
+		c := &class{name: name}
+		s.classes[name] = c
+		s.classNames = append(s.classNames, name)
+		return c
+	}
+
+	isFunctions := false
+	comment := ""
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case line == "---functions---":
+			isFunctions = true
+			comment = ""
+			continue
+		case strings.HasPrefix(line, "//-"):
+			comment += " " + line[len("//-"):]
+			continue
+		case strings.HasPrefix(line, "//@class "):
+			docs := parseDocs(line[len("//"):])
+			class := getClass(docs["class"])
+			class.description = docs["description"]
+			comment = ""
+			continue
+		case strings.HasPrefix(line, "//"):
+			comment += " " + line[len("//"):]
+			continue
+		}
+
+		docs := parseDocs(comment)
+		comment = ""
+		if result := strings.Fields(strings.TrimSuffix(line[strings.LastIndex(line, "=")+1:], ";")); len(result) > 0 && builtinTypes[result[0]] {
+			continue
+		}
+		c, err := parseCombinator(line, docs)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		if isFunctions {
+			c.isFunction = true
+			s.functions = append(s.functions, c)
+		} else {
+			s.constructors = append(s.constructors, c)
+			class := getClass(c.result)
+			class.constructors = append(class.constructors, c)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range s.classNames {
+		if len(s.classes[name].constructors) == 0 {
+			return nil, fmt.Errorf("class %s has no constructors", name)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,127 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+int32 = Int32;
+int64 = Int64;
+vector {t:Type} # [ t ] = Vector t;
+
+//@description A point @x The X coordinate
+//@y The Y coordinate
+point x:int32 y:int32 = Point;
+
+//@class Shape @description Describes a shape
+
+//@description A polygon @id Identifier of the polygon @vertices Vertices of the polygon; may be
+//-empty @param_description Description of the polygon
+shapePolygon id:int64 vertices:vector<point> description:string = Shape;
+
+//@description A group of shapes @shapes The shapes
+shapeGroup shapes:vector<vector<Shape>> = Shape;
+
+---functions---
+
+//@description Returns a shape @shape_id Identifier of the shape
+getShape shape_id:int64 = Shape;
+`
+
+func TestParseSchema(t *testing.T) {
+	s, err := parseSchema(strings.NewReader(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.constructors) != 3 || len(s.functions) != 1 {
+		t.Fatalf("got %d constructors and %d functions", len(s.constructors), len(s.functions))
+	}
+	if s.classes["Point"].isAbstract() || !s.classes["Shape"].isAbstract() {
+		t.Error("wrong abstract classes")
+	}
+	if s.classes["Shape"].description != "Describes a shape" {
+		t.Errorf("got class description %q", s.classes["Shape"].description)
+	}
+
+	point := s.constructors[0]
+	if point.description != "A point" || point.args[1].description != "The Y coordinate" {
+		t.Errorf("got descriptions %q and %q", point.description, point.args[1].description)
+	}
+	polygon := s.constructors[1]
+	if polygon.args[1].description != "Vertices of the polygon; may be empty" ||
+		polygon.args[2].description != "Description of the polygon" {
+		t.Errorf("got descriptions %q and %q", polygon.args[1].description, polygon.args[2].description)
+	}
+	if typ := s.constructors[2].args[0].typ.String(); typ != "vector<vector<Shape>>" {
+		t.Errorf("got type %s", typ)
+	}
+	if f := s.functions[0]; f.name != "getShape" || f.result != "Shape" || !f.isFunction {
+		t.Errorf("got function %+v", f)
+	}
+
+	if _, err := generate(s, "tdapi"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"chat_id":            "ChatID",
+		"message_ids":        "MessageIDs",
+		"getTMeUrls":         "GetTMeURLs",
+		"use_test_dc":        "UseTestDC",
+		"jsonValueNull":      "JSONValueNull",
+		"inputFileId":        "InputFileID",
+		"identity_document":  "IdentityDocument",
+		"authorizationState": "AuthorizationState",
+	}
+	for name, expected := range tests {
+		if got := goName(name); got != expected {
+			t.Errorf("goName(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestGenerateUnknownType(t *testing.T) {
+	s, err := parseSchema(strings.NewReader("point x:int32 y:float = Point;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generate(s, "tdapi"); err == nil {
+		t.Error("unknown type was accepted")
+	}
+}
+
+// TestGeneratedCodeIsUpToDate checks that tdapi/types.go was regenerated after the schema was changed.
+func TestGeneratedCodeIsUpToDate(t *testing.T) {
+	file, err := os.Open("../../../../td/generate/scheme/td_api.tl")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer file.Close()
+	s, err := parseSchema(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := generate(s, "tdapi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := os.ReadFile("../../tdapi/types.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(source, committed) {
+		t.Error("tdapi/types.go is out of date, run go generate ./tdapi")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/tdlib/td/example/go
+
+go 1.20
@@ -0,0 +1,89 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+// Basic example of TDLib JSON interface usage from Go.
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tdlib/td/example/go/tdjson"
+)
+
+var stdin = bufio.NewReader(os.Stdin)
+
+func readLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	line, err := stdin.ReadString('\n')
+	return strings.TrimSpace(line), err
+}
+
+func main() {
+	// check that libtdjson is found and works before doing anything else
+	if err := tdjson.SelfTest(); err != nil {
+		log.Fatal(err)
+	}
+
+	// print TDLib fatal errors before the crash
+	tdjson.SetLogMessageCallback(0, func(verbosity int, message string) {
+		if verbosity == 0 {
+			fmt.Fprintln(os.Stderr, "TDLib fatal error:", message)
+		}
+	})
+
+	manager := tdjson.NewManager()
+	defer manager.Close()
+
+	// create client
+	client := manager.NewClient()
+	defer client.Destroy()
+
+	// you MUST obtain your own api_id and api_hash at https://my.telegram.org
+	// and use them in the setTdlibParameters call
+	authorizer := &tdjson.Authorizer{
+		Parameters: tdjson.DefaultParameters(94575, "a3406de8d171bb422bb6ddf3bbd800e2").WithSecretChats(),
+		PhoneNumber: func() (string, error) {
+			return readLine("Please enter your phone number: ")
+		},
+		Code: func() (string, error) {
+			return readLine("Please enter the authentication code you received: ")
+		},
+		Password: func() (string, error) {
+			return readLine("Please enter your password: ")
+		},
+		Registration: func() (string, string, error) {
+			firstName, err := readLine("Please enter your first name: ")
+			if err != nil {
+				return "", "", err
+			}
+			lastName, err := readLine("Please enter your last name: ")
+			return firstName, lastName, err
+		},
+	}
+	if err := authorizer.Authorize(context.Background(), client); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Authorized with TDLib", tdjson.Version())
+
+	// main events cycle
+	for {
+		event, err := client.Receive(time.Second)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if event != "" {
+			// handle an incoming update or an answer to a previously sent request
+			fmt.Println(event)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+// Package tdapi contains Go types for all objects and functions of the TDLib JSON interface.
+//
+// The types in types.go are generated by cmd/tlgen from td/generate/scheme/td_api.tl and must be regenerated
+// with "go generate" after the schema is changed.
+//
+// Fields with zero values are omitted from JSON. TDLib deserializes an absent field as null, which means
+// the default value of the field's type: false, 0, an empty string or vector, or a null object. So there are no
+// tri-state request fields, for which false and absent values differ: for example, DisableNotification
+// of MessageSendOptions is an ordinary bool, which is omitted unless it is true.
+package tdapi
+
+//go:generate go run ../cmd/tlgen -o types.go ../../../td/generate/scheme/td_api.tl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Object is a TDLib object or function.
+type Object interface {
+	// ObjectType returns the "@type" of the object.
+	ObjectType() string
+}
+
+// Function is a TDLib function, which can be sent as a request.
+type Function interface {
+	Object
+	isFunction()
+}
+
+// Int64 is a 64-bit integer. It is serialized to JSON as a string, because JSON numbers
+// can't represent all 64-bit integers precisely.
+type Int64 int64
+
+// MarshalJSON serializes the integer to a JSON string.
+func (i Int64) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + strconv.FormatInt(int64(i), 10) + `"`), nil
+}
+
+// UnmarshalJSON deserializes the integer from a JSON string or a JSON number.
+func (i *Int64) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	data = bytes.Trim(data, `"`)
+	value, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("tdapi: invalid int64 value %s", data)
+	}
+	*i = Int64(value)
+	return nil
+}
+
+func isNull(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) == 0 || bytes.Equal(data, []byte("null"))
+}
+
+// Unmarshal deserializes an object of any type from JSON, choosing the type by its "@type".
+// Nil is returned for null.
+func Unmarshal(data []byte) (Object, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	var header struct {
+		Type string `json:"@type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	newObject, ok := objectTypes[header.Type]
+	if !ok {
+		return nil, fmt.Errorf("tdapi: unknown object type %q", header.Type)
+	}
+	object := newObject()
+	if err := json.Unmarshal(data, object); err != nil {
+		return nil, fmt.Errorf("tdapi: failed to unmarshal %s: %v", header.Type, err)
+	}
+	return object, nil
+}
+
+// unmarshalList splits a JSON array to its elements. Nil is returned for null.
+func unmarshalList(data []byte) ([]json.RawMessage, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	var list []json.RawMessage
+	err := json.Unmarshal(data, &list)
+	return list, err
+}
@@ -0,0 +1,146 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// withoutZeroFields removes fields with zero values, which are omitted by MarshalJSON, from the decoded JSON.
+func withoutZeroFields(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			field = withoutZeroFields(field)
+			if list, ok := field.([]interface{}); field == nil || field == false || field == 0.0 || field == "" || ok && len(list) == 0 {
+				delete(value, key)
+			}
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = withoutZeroFields(value[i])
+		}
+	}
+	return value
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		`{"@type":"error","code":400,"message":"PHONE_NUMBER_INVALID"}`,
+		`{"@type":"ok"}`,
+		`{"@type":"authorizationStateWaitCode","code_info":{"@type":"authenticationCodeInfo","phone_number":"+123456789",
+			"type":{"@type":"authenticationCodeTypeSms","length":5},"next_type":null,"timeout":60}}`,
+		`{"@type":"formattedText","text":"bold link code","entities":[
+			{"@type":"textEntity","offset":0,"length":4,"type":{"@type":"textEntityTypeBold"}},
+			{"@type":"textEntity","offset":5,"length":4,"type":{"@type":"textEntityTypeTextUrl","url":"https://telegram.org"}},
+			{"@type":"textEntity","offset":10,"length":4,"type":{"@type":"textEntityTypePreCode","language":"go"}}]}`,
+		`{"@type":"updateNewInlineQuery","id":"9223372036854775807","sender_user_id":1,
+			"user_location":{"@type":"location","latitude":53.9,"longitude":27.56,"horizontal_accuracy":0},
+			"chat_type":{"@type":"chatTypeSupergroup","supergroup_id":2,"is_channel":true},"query":"q","offset":""}`,
+		`{"@type":"replyMarkupInlineKeyboard","rows":[[
+			{"@type":"inlineKeyboardButton","text":"A","type":{"@type":"inlineKeyboardButtonTypeCallback","data":"AAEC"}},
+			{"@type":"inlineKeyboardButton","text":"B","type":{"@type":"inlineKeyboardButtonTypeUrl","url":"https://t.me"}}],[]]}`,
+		`{"@type":"jsonValueObject","members":[{"@type":"jsonObjectMember","key":"a","value":{"@type":"jsonValueArray",
+			"values":[{"@type":"jsonValueNull"},{"@type":"jsonValueBoolean","value":true},{"@type":"jsonValueNumber","value":1.5},
+			{"@type":"jsonValueString","value":"s"}]}}]}`,
+	}
+	for _, test := range tests {
+		object, err := Unmarshal([]byte(test))
+		if err != nil {
+			t.Errorf("failed to unmarshal %s: %v", test, err)
+			continue
+		}
+		data, err := json.Marshal(object)
+		if err != nil {
+			t.Errorf("failed to marshal %T: %v", object, err)
+			continue
+		}
+
+		var expected, got interface{}
+		if err := json.Unmarshal([]byte(test), &expected); err != nil {
+			t.Fatal(err)
+		}
+		expected = withoutZeroFields(expected)
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("round trip of %s returned %s", test, data)
+		}
+	}
+}
+
+func TestUnmarshalTypes(t *testing.T) {
+	object, err := Unmarshal([]byte(`{"@type":"updateNewInlineQuery","id":"-1001234567890123","chat_type":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	update, ok := object.(*UpdateNewInlineQuery)
+	if !ok {
+		t.Fatalf("got %T", object)
+	}
+	if update.ID != -1001234567890123 || update.ChatType != nil {
+		t.Errorf("got %+v", update)
+	}
+
+	state, err := UnmarshalAuthorizationState([]byte(`{"@type":"authorizationStateWaitPassword","password_hint":"hint"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password, ok := state.(*AuthorizationStateWaitPassword); !ok || password.PasswordHint != "hint" {
+		t.Errorf("got %#v", state)
+	}
+
+	if _, err := UnmarshalAuthorizationState([]byte(`{"@type":"ok"}`)); err == nil {
+		t.Error("an object of another class was accepted")
+	}
+	if _, err := Unmarshal([]byte(`{"@type":"unknownObject"}`)); err == nil {
+		t.Error("an unknown object was accepted")
+	}
+	if object, err := Unmarshal([]byte(`null`)); object != nil || err != nil {
+		t.Errorf("got %v, %v for null", object, err)
+	}
+}
+
+func TestMarshalFunction(t *testing.T) {
+	var request Function = &GetChatHistory{ChatID: -1001234567890123, Limit: 10, OnlyLocal: true}
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"@type":"getChatHistory","chat_id":-1001234567890123,"limit":10,"only_local":true}`
+	if string(data) != expected {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestMarshalOmitsZeroFields(t *testing.T) {
+	content := &InputMessageText{Text: &FormattedText{Text: "text"}}
+	tests := []struct {
+		options  *MessageSendOptions
+		expected string
+	}{
+		{nil, `{"@type":"sendMessage","chat_id":1,"input_message_content":{"@type":"inputMessageText","text":{"@type":"formattedText","text":"text"}}}`},
+		{&MessageSendOptions{}, `{"@type":"sendMessage","chat_id":1,"options":{"@type":"messageSendOptions"},"input_message_content":{"@type":"inputMessageText","text":{"@type":"formattedText","text":"text"}}}`},
+		{&MessageSendOptions{DisableNotification: true}, `{"@type":"sendMessage","chat_id":1,"options":{"@type":"messageSendOptions","disable_notification":true},"input_message_content":{"@type":"inputMessageText","text":{"@type":"formattedText","text":"text"}}}`},
+	}
+	for _, test := range tests {
+		data, err := json.Marshal(&SendMessage{ChatID: 1, Options: test.options, InputMessageContent: content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.expected {
+			t.Errorf("got %s, expected %s", data, test.expected)
+		}
+		if err := ValidateRequest(data); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ValidationError describes an invalid value in a request.
+type ValidationError struct {
+	Path    string // path to the invalid value, for example "sendMessage.input_message_content.text"
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return "tdapi: " + e.Message
+	}
+	return "tdapi: " + e.Path + ": " + e.Message
+}
+
+// ValidateRequest checks that the JSON-serialized request is a TDLib function and that all its values
+// have the types expected by TDLib. Unlike TDLib, which ignores unknown fields, it also rejects fields,
+// which aren't present in the schema. Null is accepted for any value as it is by TDLib.
+// Errors are returned as a *ValidationError.
+func ValidateRequest(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var request interface{}
+	if err := decoder.Decode(&request); err != nil {
+		return &ValidationError{Message: "invalid JSON: " + err.Error()}
+	}
+	object, ok := request.(map[string]interface{})
+	if !ok {
+		return &ValidationError{Message: "request must be a JSON object"}
+	}
+	typ, ok := object["@type"].(string)
+	if !ok {
+		return &ValidationError{Message: `request must have a string "@type"`}
+	}
+	newFunction, ok := functionTypes[typ]
+	if !ok {
+		if _, ok := objectTypes[typ]; ok {
+			return &ValidationError{Path: typ, Message: "is an object, not a function"}
+		}
+		return &ValidationError{Path: typ, Message: "unknown function"}
+	}
+	return validateObject(typ, typ, reflect.TypeOf(newFunction()).Elem(), object, true)
+}
+
+// schemaFields caches the fields of structs by their JSON names.
+var schemaFields sync.Map // reflect.Type -> map[string]reflect.Type
+
+func fieldsOf(t reflect.Type) map[string]reflect.Type {
+	if fields, ok := schemaFields.Load(t); ok {
+		return fields.(map[string]reflect.Type)
+	}
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = field.Type
+		}
+	}
+	schemaFields.Store(t, fields)
+	return fields
+}
+
+func validateObject(path string, typ string, t reflect.Type, object map[string]interface{}, isRequest bool) error {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	// the first invalid field is reported independently of the map order
+	sort.Strings(keys)
+
+	fields := fieldsOf(t)
+	for _, key := range keys {
+		if key == "@type" || (isRequest && key == "@extra") {
+			continue
+		}
+		fieldType, ok := fields[key]
+		if !ok {
+			return &ValidationError{Path: path + "." + key, Message: "unknown field of " + typ}
+		}
+		if err := validateValue(path+"."+key, fieldType, object[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	int64Type = reflect.TypeOf(Int64(0))
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+func validateValue(path string, t reflect.Type, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	invalid := func(expected string) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", expected, jsonType(value))}
+	}
+
+	switch {
+	case t == int64Type || t.Kind() == reflect.Int32 || t.Kind() == reflect.Int64:
+		if !isInteger(value, t.Bits()) {
+			return invalid(fmt.Sprintf("%d-bit integer", t.Bits()))
+		}
+	case t.Kind() == reflect.Bool:
+		// TDLib also accepts integers as booleans
+		if _, ok := value.(bool); !ok && !isInteger(value, 32) {
+			return invalid("boolean")
+		}
+	case t.Kind() == reflect.Float64:
+		if _, ok := value.(json.Number); !ok {
+			return invalid("number")
+		}
+	case t.Kind() == reflect.String:
+		if _, ok := value.(string); !ok {
+			return invalid("string")
+		}
+	case t == bytesType:
+		s, ok := value.(string)
+		if !ok {
+			return invalid("base64-encoded string")
+		}
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			return invalid("base64-encoded string")
+		}
+	case t.Kind() == reflect.Slice:
+		list, ok := value.([]interface{})
+		if !ok {
+			return invalid("array")
+		}
+		for i, element := range list {
+			if err := validateValue(path+"["+strconv.Itoa(i)+"]", t.Elem(), element); err != nil {
+				return err
+			}
+		}
+	case t.Kind() == reflect.Ptr:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return invalid("object")
+		}
+		typ := reflect.New(t.Elem()).Interface().(Object).ObjectType()
+		// "@type" of an object of a class with one constructor isn't required
+		if rawType, ok := object["@type"]; ok && rawType != typ {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %v", typ, rawType)}
+		}
+		return validateObject(path, typ, t.Elem(), object, false)
+	case t.Kind() == reflect.Interface:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return invalid("object")
+		}
+		class := t.Name()
+		typ, ok := object["@type"].(string)
+		if !ok {
+			return &ValidationError{Path: path, Message: `"@type" of ` + class + " must be specified"}
+		}
+		newObject, ok := objectTypes[typ]
+		if !ok {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("unknown type %q", typ)}
+		}
+		concreteType := reflect.TypeOf(newObject())
+		if !concreteType.Implements(t) {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", class, typ)}
+		}
+		return validateObject(path, typ, concreteType.Elem(), object, false)
+	}
+	return nil
+}
+
+// isInteger returns true if the value is a JSON number or a string containing an integer of the given size.
+func isInteger(value interface{}, bits int) bool {
+	var s string
+	switch value := value.(type) {
+	case json.Number:
+		s = value.String()
+	case string:
+		s = value
+	default:
+		return false
+	}
+	_, err := strconv.ParseInt(s, 10, bits)
+	return err == nil
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "null"
+}
@@ -0,0 +1,77 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdapi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateRequestValid(t *testing.T) {
+	tests := []string{
+		`{"@type":"getMe"}`,
+		`{"@type":"getMe","@extra":{"id":1}}`,
+		`{"@type":"sendMessage","chat_id":-1001234567890123,"message_thread_id":0,"reply_to_message_id":"0",
+			"options":null,"reply_markup":{"@type":"replyMarkupInlineKeyboard","rows":[[{"text":"A",
+			"type":{"@type":"inlineKeyboardButtonTypeCallback","data":"AAEC"}}]]},
+			"input_message_content":{"@type":"inputMessageText","text":{"@type":"formattedText","text":"hi",
+			"entities":[{"offset":0,"length":2,"type":{"@type":"textEntityTypeBold"}}]},"clear_draft":1}}`,
+		`{"@type":"setOption","name":"online","value":{"@type":"optionValueBoolean","value":true}}`,
+		`{"@type":"setLocation","location":{"latitude":53.9,"longitude":27,"horizontal_accuracy":0}}`,
+		`{"@type":"answerInlineQuery","inline_query_id":"9223372036854775807","results":[]}`,
+	}
+	for _, test := range tests {
+		if err := ValidateRequest([]byte(test)); err != nil {
+			t.Errorf("%s: %v", test, err)
+		}
+	}
+}
+
+func TestValidateRequestInvalid(t *testing.T) {
+	tests := []struct {
+		request string
+		path    string
+	}{
+		{`[]`, ""},
+		{`{"@type":1}`, ""},
+		{`{"@type":"sendMesage","chat_id":1}`, "sendMesage"},
+		{`{"@type":"message"}`, "message"},
+		{`{"@type":"getChat","chatid":1}`, "getChat.chatid"},
+		{`{"@type":"getChat","chat_id":"one"}`, "getChat.chat_id"},
+		{`{"@type":"getChat","chat_id":1.5}`, "getChat.chat_id"},
+		{`{"@type":"getUser","user_id":4294967296}`, "getUser.user_id"},
+		{`{"@type":"setLocation","location":{"latitude":"53.9"}}`, "setLocation.location.latitude"},
+		{`{"@type":"setLocation","location":{"@type":"venue"}}`, "setLocation.location"},
+		{`{"@type":"sendMessage","input_message_content":{"text":{"text":"hi"}}}`, "sendMessage.input_message_content"},
+		{`{"@type":"sendMessage","input_message_content":{"@type":"inputMessageText","text":{"txt":"hi"}}}`,
+			"sendMessage.input_message_content.text.txt"},
+		{`{"@type":"sendMessage","input_message_content":{"@type":"formattedText"}}`, "sendMessage.input_message_content"},
+		{`{"@type":"sendMessage","input_message_content":{"@type":"inputMessageText","text":{"entities":[{},
+			{"type":{"@type":"textEntityTypeBld"}}]}}}`, "sendMessage.input_message_content.text.entities[1].type"},
+		{`{"@type":"checkDatabaseEncryptionKey","encryption_key":"not base64!"}`, "checkDatabaseEncryptionKey.encryption_key"},
+		{`{"@type":"getChats","chat_list":{"@type":"chatListMain","@extra":1}}`, "getChats.chat_list.@extra"},
+	}
+	for _, test := range tests {
+		err := ValidateRequest([]byte(test.request))
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%s: got error %v", test.request, err)
+			continue
+		}
+		if validationErr.Path != test.path {
+			t.Errorf("%s: got error %v", test.request, err)
+		}
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := ValidateRequest([]byte(`{"@type":"getChat","chatid":1}`))
+	if err == nil || err.Error() != "tdapi: getChat.chatid: unknown field of getChat" {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrClientClosed is returned when TDLib instance is closed, or its Manager is closed, and it can't be used anymore.
+var ErrClientClosed = errors.New("tdjson: client is closed")
+
+// ErrBadParameters is returned by Authorize if TDLib rejects the TDLib parameters, for example because api_id
+// is invalid or the database directory isn't writable, or can't open the database with them.
+// The returned error wraps the *Error returned by TDLib.
+var ErrBadParameters = errors.New("tdjson: bad TDLib parameters")
+
+// badParametersError is an error in response to setTdlibParameters or checkDatabaseEncryptionKey,
+// or an error caused by invalid api_id.
+type badParametersError struct {
+	reason string
+	err    *Error
+}
+
+// newBadParametersError returns an error with the reason of a well-known TDLib error, or with the given reason.
+func newBadParametersError(err *Error, reason string) *badParametersError {
+	switch {
+	case strings.HasPrefix(err.Message, "Valid api_id"), err.Message == "API_ID_INVALID":
+		reason = "invalid api_id, it can be obtained at https://my.telegram.org"
+	case err.Message == "API_ID_PUBLISHED_FLOOD":
+		reason = "api_id was published and can't be used, obtain your own at https://my.telegram.org"
+	case strings.HasPrefix(err.Message, "Valid api_hash"):
+		reason = "invalid api_hash, it can be obtained at https://my.telegram.org"
+	case strings.HasPrefix(err.Message, "Can't init database"):
+		reason = "database directory isn't writable"
+	case strings.HasPrefix(err.Message, "Can't init files directory"):
+		reason = "files directory isn't writable"
+	}
+	return &badParametersError{reason: reason, err: err}
+}
+
+func (e *badParametersError) Error() string {
+	return fmt.Sprintf("%v: %s: %s", ErrBadParameters, e.reason, e.err.Message)
+}
+
+func (e *badParametersError) Is(target error) bool {
+	return target == ErrBadParameters
+}
+
+func (e *badParametersError) Unwrap() error {
+	return e.err
+}
+
+// isAPIIDError returns true if the error is returned by Telegram for requests of an application with invalid api_id.
+func isAPIIDError(err error) bool {
+	var tdErr *Error
+	return errors.As(err, &tdErr) && (tdErr.Message == "API_ID_INVALID" || tdErr.Message == "API_ID_PUBLISHED_FLOOD")
+}
+
+// Authorizer drives a client through the authorization flow.
+// An input callback is called again if TDLib rejects the value returned by it.
+type Authorizer struct {
+	Parameters *TdlibParameters
+
+	// PhoneNumber returns the phone number of the user.
+	PhoneNumber func() (string, error)
+
+	// Code returns the authentication code sent to the user. If nil and Parameters.UseTestDC is true,
+	// the code for test phone numbers is entered automatically, as returned by TestDCAuthenticationCode.
+	Code func() (string, error)
+
+	// Password returns the 2-step verification password of the user.
+	Password func() (string, error)
+
+	// Registration returns the first and the last name of a new user. If nil, registration of new users fails.
+	Registration func() (firstName string, lastName string, err error)
+
+	// OnQRLink is called with a link, which must be shown to the user as a QR code to be scanned by another
+	// logged in device of the user. If not nil, the user is authorized by a QR code instead of the phone number.
+	// It is called again with a new link whenever the previous link expires.
+	OnQRLink func(link string)
+}
+
+// Authorize performs authorization of the client and returns after the authorization state
+// becomes authorizationStateReady. ErrClientClosed is returned if the client is closed before that.
+// If TDLib rejects the TDLib parameters, an error matching ErrBadParameters is returned.
+//
+// Events are received by the client as usual, so for clients created by NewClient
+// some goroutine must be calling Receive.
+func (a *Authorizer) Authorize(ctx context.Context, c *Client) error {
+	// the current state is returned in response to getAuthorizationState and in updates
+	// afterwards, so they are handled in the order in which they are received
+	sub := c.subscribe(func(header *eventHeader) bool {
+		return header.Type == "updateAuthorizationState" || strings.HasPrefix(header.Type, "authorizationState")
+	})
+	defer sub.close()
+	if err := c.Send(`{"@type":"getAuthorizationState"}`); err != nil {
+		return err
+	}
+
+	var lastState AuthorizationStateType
+	var lastLink string
+	for {
+		event, err := sub.next(ctx)
+		if err != nil {
+			return err
+		}
+
+		state := authorizationStateType(event)
+		if state == "" {
+			return fmt.Errorf("tdjson: failed to parse authorization state: %s", event)
+		}
+		// the state is the same when a new QR code link is sent after the previous link expires
+		link := ""
+		if state == TypeAuthorizationStateWaitOtherDeviceConfirmation {
+			link = otherDeviceConfirmationLink(event)
+		}
+		if state == lastState && link == lastLink {
+			continue
+		}
+		lastState = state
+		lastLink = link
+
+		if state == TypeAuthorizationStateWaitOtherDeviceConfirmation {
+			if a.OnQRLink == nil {
+				return errors.New("tdjson: QR code authentication callback isn't specified")
+			}
+			a.OnQRLink(link)
+			continue
+		}
+		done, err := a.handleState(ctx, c, state, event)
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+// authorizationStateType returns the "@type" of the authorization state in updateAuthorizationState,
+// or the "@type" of the object itself for other objects.
+func authorizationStateType(event string) AuthorizationStateType {
+	var object struct {
+		Type               AuthorizationStateType `json:"@type"`
+		AuthorizationState struct {
+			Type AuthorizationStateType `json:"@type"`
+		} `json:"authorization_state"`
+	}
+	if err := json.Unmarshal([]byte(event), &object); err != nil {
+		return ""
+	}
+	if object.Type == "updateAuthorizationState" {
+		return object.AuthorizationState.Type
+	}
+	return object.Type
+}
+
+// otherDeviceConfirmationLink returns the link of authorizationStateWaitOtherDeviceConfirmation
+// in updateAuthorizationState or of the state itself.
+func otherDeviceConfirmationLink(event string) string {
+	var object struct {
+		Link               string `json:"link"`
+		AuthorizationState struct {
+			Link string `json:"link"`
+		} `json:"authorization_state"`
+	}
+	if err := json.Unmarshal([]byte(event), &object); err != nil {
+		return ""
+	}
+	if object.Link != "" {
+		return object.Link
+	}
+	return object.AuthorizationState.Link
+}
+
+// codePhoneNumber returns the phone number, to which the authentication code was sent,
+// from authorizationStateWaitCode in updateAuthorizationState or from the state itself.
+func codePhoneNumber(event string) string {
+	type codeInfo struct {
+		PhoneNumber string `json:"phone_number"`
+	}
+	var object struct {
+		CodeInfo           codeInfo `json:"code_info"`
+		AuthorizationState struct {
+			CodeInfo codeInfo `json:"code_info"`
+		} `json:"authorization_state"`
+	}
+	if err := json.Unmarshal([]byte(event), &object); err != nil {
+		return ""
+	}
+	if object.CodeInfo.PhoneNumber != "" {
+		return object.CodeInfo.PhoneNumber
+	}
+	return object.AuthorizationState.CodeInfo.PhoneNumber
+}
+
+// TestDCAuthenticationCode returns the authentication code for test phone numbers of the test DC with the given
+// number. Test phone numbers have the form 99966XYYYY, where X is the DC number from 1 to 3 and YYYY are
+// random digits. Such numbers don't receive codes, instead the code is the DC number repeated 5 times.
+func TestDCAuthenticationCode(dc int) string {
+	return strings.Repeat(strconv.Itoa(dc), 5)
+}
+
+// testDCNumber returns the number of the DC of a test phone number, or 0 for other phone numbers.
+func testDCNumber(phoneNumber string) int {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, phoneNumber)
+	if len(digits) != 10 || !strings.HasPrefix(digits, "99966") || digits[5] < '1' || digits[5] > '3' {
+		return 0
+	}
+	return int(digits[5] - '0')
+}
+
+// handleState sends a request needed to leave the authorization state received in the event.
+// It returns true if the state is final.
+func (a *Authorizer) handleState(ctx context.Context, c *Client, state AuthorizationStateType, event string) (bool, error) {
+	switch state {
+	case TypeAuthorizationStateWaitTdlibParameters:
+		if a.Parameters == nil {
+			return false, errors.New("tdjson: TDLib parameters aren't specified")
+		}
+		_, err := c.Call(ctx, map[string]interface{}{"@type": "setTdlibParameters", "parameters": a.Parameters})
+		var tdErr *Error
+		if errors.As(err, &tdErr) {
+			return false, newBadParametersError(tdErr, "TDLib rejected the parameters")
+		}
+		return false, err
+
+	case TypeAuthorizationStateWaitEncryptionKey:
+		// bytes are serialized to JSON in base64 encoding
+		key := []byte{}
+		if a.Parameters != nil && a.Parameters.DatabaseEncryptionKey != nil {
+			key = a.Parameters.DatabaseEncryptionKey
+		}
+		// the database is opened only after the key is checked, so the errors of opening it are returned here
+		_, err := c.Call(ctx, map[string]interface{}{"@type": "checkDatabaseEncryptionKey", "encryption_key": key})
+		var tdErr *Error
+		if errors.As(err, &tdErr) {
+			return false, newBadParametersError(tdErr, "can't open database")
+		}
+		return false, err
+
+	case TypeAuthorizationStateWaitPhoneNumber:
+		if a.OnQRLink != nil {
+			_, err := c.Call(ctx, map[string]interface{}{"@type": "requestQrCodeAuthentication", "other_user_ids": []int32{}})
+			return false, err
+		}
+		err := a.retry(ctx, c, a.PhoneNumber, func(phoneNumber string) map[string]interface{} {
+			return map[string]interface{}{"@type": "setAuthenticationPhoneNumber", "phone_number": phoneNumber}
+		})
+		// api_id is checked by Telegram only when the authentication code is requested
+		var tdErr *Error
+		if errors.As(err, &tdErr) && isAPIIDError(err) {
+			return false, newBadParametersError(tdErr, "Telegram rejected api_id")
+		}
+		return false, err
+
+	case TypeAuthorizationStateWaitCode:
+		if a.Code == nil && a.Parameters != nil && a.Parameters.UseTestDC {
+			if dc := testDCNumber(codePhoneNumber(event)); dc != 0 {
+				// the code is checked only once, because it can't be wrong
+				_, err := c.Call(ctx, map[string]interface{}{"@type": "checkAuthenticationCode", "code": TestDCAuthenticationCode(dc)})
+				return false, err
+			}
+		}
+		return false, a.retry(ctx, c, a.Code, func(code string) map[string]interface{} {
+			return map[string]interface{}{"@type": "checkAuthenticationCode", "code": code}
+		})
+
+	case TypeAuthorizationStateWaitPassword:
+		return false, a.retry(ctx, c, a.Password, func(password string) map[string]interface{} {
+			return map[string]interface{}{"@type": "checkAuthenticationPassword", "password": password}
+		})
+
+	case TypeAuthorizationStateWaitRegistration:
+		if a.Registration == nil {
+			return false, errors.New("tdjson: registration of new users isn't supported")
+		}
+		for {
+			firstName, lastName, err := a.Registration()
+			if err != nil {
+				return false, err
+			}
+			_, err = c.Call(ctx, map[string]interface{}{"@type": "registerUser", "first_name": firstName, "last_name": lastName})
+			if !isInputError(err) {
+				return false, err
+			}
+		}
+
+	case TypeAuthorizationStateReady:
+		return true, nil
+
+	case TypeAuthorizationStateClosed:
+		return true, ErrClientClosed
+	}
+	return false, nil
+}
+
+// retry sends the request built from the value returned by input until TDLib accepts the value.
+func (a *Authorizer) retry(ctx context.Context, c *Client, input func() (string, error), request func(string) map[string]interface{}) error {
+	if input == nil {
+		return errors.New("tdjson: authorization callback isn't specified")
+	}
+	for {
+		value, err := input()
+		if err != nil {
+			return err
+		}
+		_, err = c.Call(ctx, request(value))
+		if !isInputError(err) {
+			return err
+		}
+	}
+}
+
+// isInputError returns true if the error is a TDLib error caused by an invalid value entered by the user.
+func isInputError(err error) bool {
+	var tdErr *Error
+	return errors.As(err, &tdErr) && tdErr.Code == 400 && !isAPIIDError(err)
+}
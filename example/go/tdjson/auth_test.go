@@ -0,0 +1,331 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptAuthorization makes the backend answer authorization requests by advancing through the states.
+// The wrong code is rejected once.
+func scriptAuthorization(td *fakeBackend) *[]string {
+	var mu sync.Mutex
+	var requests []string
+	ok := func(clientID int, request map[string]interface{}) {
+		td.push(clientID, map[string]interface{}{"@type": "ok", "@extra": request["@extra"]})
+	}
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		typ := request["@type"].(string)
+		mu.Lock()
+		requests = append(requests, typ)
+		mu.Unlock()
+
+		next := ""
+		switch typ {
+		case "getAuthorizationState":
+			td.push(clientID, map[string]interface{}{"@type": "authorizationStateWaitTdlibParameters"})
+			return
+		case "setTdlibParameters":
+			next = "authorizationStateWaitEncryptionKey"
+		case "checkDatabaseEncryptionKey":
+			if request["encryption_key"] != "c2VjcmV0IGtleQ==" {
+				td.push(clientID, map[string]interface{}{"@type": "error", "code": 401, "message": "Wrong database encryption key", "@extra": request["@extra"]})
+				return
+			}
+			next = "authorizationStateWaitPhoneNumber"
+		case "setAuthenticationPhoneNumber":
+			next = "authorizationStateWaitCode"
+		case "checkAuthenticationCode":
+			if request["code"] != "12345" {
+				td.push(clientID, map[string]interface{}{"@type": "error", "code": 400, "message": "PHONE_CODE_INVALID", "@extra": request["@extra"]})
+				return
+			}
+			next = "authorizationStateWaitPassword"
+		case "checkAuthenticationPassword":
+			next = "authorizationStateReady"
+		}
+		td.push(clientID, authorizationStateUpdate(next))
+		ok(clientID, request)
+	})
+	return &requests
+}
+
+func TestAuthorizerCallbackOrder(t *testing.T) {
+	client, td := newFakeClient(t)
+	requests := scriptAuthorization(td)
+	// the state is also sent as an update before the response to getAuthorizationState
+	td.push(client.clientID, authorizationStateUpdate("authorizationStateWaitTdlibParameters"))
+
+	var calls []string
+	codes := []string{"11111", "12345"}
+	a := &Authorizer{
+		Parameters: (&TdlibParameters{APIID: 94575, APIHash: "a3406de8d171bb422bb6ddf3bbd800e2"}).
+			WithDatabaseEncryptionKey([]byte("secret key")),
+		PhoneNumber: func() (string, error) {
+			calls = append(calls, "phone")
+			return "+123456789", nil
+		},
+		Code: func() (string, error) {
+			calls = append(calls, "code")
+			code := codes[0]
+			codes = codes[1:]
+			return code, nil
+		},
+		Password: func() (string, error) {
+			calls = append(calls, "password")
+			return "secret", nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Authorize(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"phone", "code", "code", "password"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("got callbacks %v, want %v", calls, want)
+	}
+	want := []string{
+		"getAuthorizationState",
+		"setTdlibParameters",
+		"checkDatabaseEncryptionKey",
+		"setAuthenticationPhoneNumber",
+		"checkAuthenticationCode",
+		"checkAuthenticationCode",
+		"checkAuthenticationPassword",
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("got requests %v, want %v", *requests, want)
+	}
+}
+
+func TestAuthorizerClosed(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		td.push(clientID, authorizationStateUpdate("authorizationStateClosing"))
+		td.push(clientID, authorizationStateUpdate("authorizationStateClosed"))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := (&Authorizer{}).Authorize(ctx, client); err != ErrClientClosed {
+		t.Errorf("got error %v, want %v", err, ErrClientClosed)
+	}
+}
+
+func TestAuthorizerQRCode(t *testing.T) {
+	client, td := newFakeClient(t)
+	confirmation := func(link string) map[string]interface{} {
+		update := authorizationStateUpdate("authorizationStateWaitOtherDeviceConfirmation")
+		update["authorization_state"].(map[string]interface{})["link"] = link
+		return update
+	}
+	var requests []string
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		typ := request["@type"].(string)
+		requests = append(requests, typ)
+		switch typ {
+		case "getAuthorizationState":
+			td.push(clientID, map[string]interface{}{"@type": "authorizationStateWaitPhoneNumber"})
+		case "requestQrCodeAuthentication":
+			if ids, ok := request["other_user_ids"].([]interface{}); !ok || len(ids) != 0 {
+				t.Errorf("got other_user_ids %v", request["other_user_ids"])
+			}
+			td.push(clientID, confirmation("tg://login?token=first"))
+			td.push(clientID, map[string]interface{}{"@type": "ok", "@extra": request["@extra"]})
+		}
+	})
+
+	var links []string
+	a := &Authorizer{
+		PhoneNumber: func() (string, error) {
+			t.Error("phone number was requested")
+			return "", nil
+		},
+		OnQRLink: func(link string) {
+			links = append(links, link)
+			switch link {
+			case "tg://login?token=first":
+				// the link expires and the same link must not be reported twice
+				td.push(client.clientID, confirmation(link))
+				td.push(client.clientID, confirmation("tg://login?token=second"))
+			case "tg://login?token=second":
+				// the code is scanned
+				td.push(client.clientID, authorizationStateUpdate("authorizationStateReady"))
+			}
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Authorize(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"tg://login?token=first", "tg://login?token=second"}; !reflect.DeepEqual(links, want) {
+		t.Errorf("got links %v, want %v", links, want)
+	}
+	if want := []string{"getAuthorizationState", "requestQrCodeAuthentication"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("got requests %v, want %v", requests, want)
+	}
+}
+
+func TestAuthorizerUnexpectedQRCode(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "getAuthorizationState" {
+			td.push(clientID, map[string]interface{}{"@type": "authorizationStateWaitOtherDeviceConfirmation", "link": "tg://login?token=t"})
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := (&Authorizer{}).Authorize(ctx, client); err == nil {
+		t.Error("QR code authentication succeeded without the callback")
+	}
+}
+
+func TestTestDCAuthenticationCode(t *testing.T) {
+	for dc, code := range map[int]string{1: "11111", 2: "22222", 3: "33333"} {
+		if got := TestDCAuthenticationCode(dc); got != code {
+			t.Errorf("TestDCAuthenticationCode(%d) = %q, want %q", dc, got, code)
+		}
+	}
+	tests := map[string]int{
+		"9996621234":    2,
+		"+99966 3 1234": 3,
+		"9996641234":    0,
+		"99966212345":   0,
+		"+123456789":    0,
+	}
+	for phoneNumber, dc := range tests {
+		if got := testDCNumber(phoneNumber); got != dc {
+			t.Errorf("testDCNumber(%q) = %d, want %d", phoneNumber, got, dc)
+		}
+	}
+}
+
+func TestAuthorizerTestDCCode(t *testing.T) {
+	client, td := newFakeClient(t)
+	var codes []interface{}
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "getAuthorizationState":
+			td.push(clientID, map[string]interface{}{
+				"@type":     "authorizationStateWaitCode",
+				"code_info": map[string]interface{}{"@type": "authenticationCodeInfo", "phone_number": "9996621234"},
+			})
+		case "checkAuthenticationCode":
+			codes = append(codes, request["code"])
+			td.push(clientID, authorizationStateUpdate("authorizationStateReady"))
+			td.push(clientID, map[string]interface{}{"@type": "ok", "@extra": request["@extra"]})
+		}
+	})
+
+	a := &Authorizer{Parameters: DefaultParameters(94575, "a3406de8d171bb422bb6ddf3bbd800e2").WithTestDC()}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Authorize(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"22222"}; !reflect.DeepEqual(codes, want) {
+		t.Errorf("got codes %v, want %v", codes, want)
+	}
+}
+
+func TestAuthorizerBadParameters(t *testing.T) {
+	tests := []struct {
+		message string
+		reason  string
+	}{
+		{"Valid api_id must be provided. Can be obtained at https://my.telegram.org", "invalid api_id"},
+		{`Can't init database in the directory "/db": [Error : 13 : Permission denied]`, "database directory isn't writable"},
+		{`Can't init files directory "/files": [Error : 13 : Permission denied]`, "files directory isn't writable"},
+		{"Strings must be encoded in UTF-8", "TDLib rejected the parameters"},
+	}
+	for _, test := range tests {
+		client, td := newFakeClient(t)
+		td.handleSend(func(clientID int, request map[string]interface{}) {
+			switch request["@type"] {
+			case "getAuthorizationState":
+				td.push(clientID, map[string]interface{}{"@type": "authorizationStateWaitTdlibParameters"})
+			case "setTdlibParameters":
+				td.push(clientID, map[string]interface{}{"@type": "error", "code": 400, "message": test.message, "@extra": request["@extra"]})
+			}
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := (&Authorizer{Parameters: DefaultParameters(94575, "a3406de8d171bb422bb6ddf3bbd800e2")}).Authorize(ctx, client)
+		cancel()
+		var tdErr *Error
+		if !errors.Is(err, ErrBadParameters) || !errors.As(err, &tdErr) || tdErr.Message != test.message {
+			t.Errorf("got error %v for %q", err, test.message)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("error %q doesn't contain %q", err, test.reason)
+		}
+	}
+}
+
+func TestAuthorizerInvalidAPIID(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "getAuthorizationState":
+			td.push(clientID, map[string]interface{}{"@type": "authorizationStateWaitPhoneNumber"})
+		case "setAuthenticationPhoneNumber":
+			td.push(clientID, map[string]interface{}{"@type": "error", "code": 400, "message": "API_ID_INVALID", "@extra": request["@extra"]})
+		}
+	})
+
+	var calls int
+	a := &Authorizer{
+		PhoneNumber: func() (string, error) {
+			calls++
+			return "+123456789", nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// the phone number isn't the reason of the error, so it must not be requested again
+	if err := a.Authorize(ctx, client); !errors.Is(err, ErrBadParameters) || !strings.Contains(err.Error(), "invalid api_id") {
+		t.Errorf("got error %v, want %v", err, ErrBadParameters)
+	}
+	if calls != 1 {
+		t.Errorf("phone number was requested %d times", calls)
+	}
+}
+
+func TestAuthorizerDatabaseOpenError(t *testing.T) {
+	client, td := newFakeClient(t)
+	const message = "Wrong password"
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "getAuthorizationState":
+			td.push(clientID, map[string]interface{}{"@type": "authorizationStateWaitEncryptionKey", "is_encrypted": true})
+		case "checkDatabaseEncryptionKey":
+			td.push(clientID, map[string]interface{}{"@type": "error", "code": 400, "message": message, "@extra": request["@extra"]})
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := (&Authorizer{Parameters: DefaultParameters(94575, "a3406de8d171bb422bb6ddf3bbd800e2")}).Authorize(ctx, client)
+	var tdErr *Error
+	if !errors.Is(err, ErrBadParameters) || !errors.As(err, &tdErr) || tdErr.Message != message {
+		t.Fatalf("got error %v, want %v", err, ErrBadParameters)
+	}
+	if !strings.Contains(err.Error(), "can't open database") {
+		t.Errorf("error %q doesn't contain the reason", err)
+	}
+}
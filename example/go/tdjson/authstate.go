@@ -0,0 +1,192 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AuthorizationStateType is the "@type" of an authorization state.
+type AuthorizationStateType string
+
+// Types of authorization states.
+const (
+	TypeAuthorizationStateWaitTdlibParameters         AuthorizationStateType = "authorizationStateWaitTdlibParameters"
+	TypeAuthorizationStateWaitEncryptionKey           AuthorizationStateType = "authorizationStateWaitEncryptionKey"
+	TypeAuthorizationStateWaitPhoneNumber             AuthorizationStateType = "authorizationStateWaitPhoneNumber"
+	TypeAuthorizationStateWaitCode                    AuthorizationStateType = "authorizationStateWaitCode"
+	TypeAuthorizationStateWaitOtherDeviceConfirmation AuthorizationStateType = "authorizationStateWaitOtherDeviceConfirmation"
+	TypeAuthorizationStateWaitRegistration            AuthorizationStateType = "authorizationStateWaitRegistration"
+	TypeAuthorizationStateWaitPassword                AuthorizationStateType = "authorizationStateWaitPassword"
+	TypeAuthorizationStateReady                       AuthorizationStateType = "authorizationStateReady"
+	TypeAuthorizationStateLoggingOut                  AuthorizationStateType = "authorizationStateLoggingOut"
+	TypeAuthorizationStateClosing                     AuthorizationStateType = "authorizationStateClosing"
+	TypeAuthorizationStateClosed                      AuthorizationStateType = "authorizationStateClosed"
+)
+
+// AuthState is an authorization state of a client. It is one of AuthorizationState* types.
+type AuthState interface {
+	AuthorizationStateType() AuthorizationStateType
+}
+
+// AuthorizationStateWaitTdlibParameters means that TDLib needs TdlibParameters for initialization.
+type AuthorizationStateWaitTdlibParameters struct{}
+
+// AuthorizationStateWaitEncryptionKey means that TDLib needs an encryption key to decrypt the local database.
+type AuthorizationStateWaitEncryptionKey struct {
+	IsEncrypted bool `json:"is_encrypted"`
+}
+
+// AuthorizationStateWaitPhoneNumber means that TDLib needs the user's phone number to authorize.
+type AuthorizationStateWaitPhoneNumber struct{}
+
+// AuthorizationStateWaitCode means that TDLib needs the user's authentication code to authorize.
+type AuthorizationStateWaitCode struct {
+	CodeInfo AuthenticationCodeInfo `json:"code_info"`
+}
+
+// AuthorizationStateWaitOtherDeviceConfirmation means that the user needs to confirm authorization
+// on another logged in device by scanning a QR code with the provided link.
+type AuthorizationStateWaitOtherDeviceConfirmation struct {
+	Link string `json:"link"`
+}
+
+// AuthorizationStateWaitRegistration means that the user is unregistered and needs to accept terms of service
+// and enter their first name and last name to finish registration.
+type AuthorizationStateWaitRegistration struct {
+	TermsOfService TermsOfService `json:"terms_of_service"`
+}
+
+// AuthorizationStateWaitPassword means that the user needs to enter the 2-step verification password.
+type AuthorizationStateWaitPassword struct {
+	PasswordHint                string `json:"password_hint"`
+	HasRecoveryEmailAddress     bool   `json:"has_recovery_email_address"`
+	RecoveryEmailAddressPattern string `json:"recovery_email_address_pattern"`
+}
+
+// AuthorizationStateReady means that the user has been successfully authorized.
+type AuthorizationStateReady struct{}
+
+// AuthorizationStateLoggingOut means that the user is currently logging out.
+type AuthorizationStateLoggingOut struct{}
+
+// AuthorizationStateClosing means that TDLib is closing.
+type AuthorizationStateClosing struct{}
+
+// AuthorizationStateClosed means that TDLib client is in its final state and can't be used anymore.
+type AuthorizationStateClosed struct{}
+
+// AuthenticationCodeInfo represents an authenticationCodeInfo object, information about the sent authentication code.
+type AuthenticationCodeInfo struct {
+	PhoneNumber string                  `json:"phone_number"`
+	Type        AuthenticationCodeType  `json:"type"`
+	NextType    *AuthenticationCodeType `json:"next_type"` // may be nil
+	Timeout     int32                   `json:"timeout"`
+}
+
+// AuthenticationCodeType represents any of authenticationCodeType* objects. Fields, which aren't used by the type,
+// are empty.
+type AuthenticationCodeType struct {
+	Type    string `json:"@type"`
+	Length  int32  `json:"length"`  // all types except authenticationCodeTypeFlashCall
+	Pattern string `json:"pattern"` // authenticationCodeTypeFlashCall
+}
+
+// TermsOfService represents a termsOfService object.
+type TermsOfService struct {
+	Text       FormattedText `json:"text"`
+	MinUserAge int32         `json:"min_user_age"`
+	ShowPopup  bool          `json:"show_popup"`
+}
+
+func (*AuthorizationStateWaitTdlibParameters) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateWaitTdlibParameters
+}
+
+func (*AuthorizationStateWaitEncryptionKey) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateWaitEncryptionKey
+}
+
+func (*AuthorizationStateWaitPhoneNumber) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateWaitPhoneNumber
+}
+
+func (*AuthorizationStateWaitCode) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateWaitCode
+}
+
+func (*AuthorizationStateWaitOtherDeviceConfirmation) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateWaitOtherDeviceConfirmation
+}
+
+func (*AuthorizationStateWaitRegistration) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateWaitRegistration
+}
+
+func (*AuthorizationStateWaitPassword) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateWaitPassword
+}
+
+func (*AuthorizationStateReady) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateReady
+}
+
+func (*AuthorizationStateLoggingOut) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateLoggingOut
+}
+
+func (*AuthorizationStateClosing) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateClosing
+}
+
+func (*AuthorizationStateClosed) AuthorizationStateType() AuthorizationStateType {
+	return TypeAuthorizationStateClosed
+}
+
+// UnmarshalAuthorizationState parses a JSON-serialized authorization state object.
+func UnmarshalAuthorizationState(data json.RawMessage) (AuthState, error) {
+	var header struct {
+		Type AuthorizationStateType `json:"@type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+
+	var state AuthState
+	switch header.Type {
+	case TypeAuthorizationStateWaitTdlibParameters:
+		state = &AuthorizationStateWaitTdlibParameters{}
+	case TypeAuthorizationStateWaitEncryptionKey:
+		state = &AuthorizationStateWaitEncryptionKey{}
+	case TypeAuthorizationStateWaitPhoneNumber:
+		state = &AuthorizationStateWaitPhoneNumber{}
+	case TypeAuthorizationStateWaitCode:
+		state = &AuthorizationStateWaitCode{}
+	case TypeAuthorizationStateWaitOtherDeviceConfirmation:
+		state = &AuthorizationStateWaitOtherDeviceConfirmation{}
+	case TypeAuthorizationStateWaitRegistration:
+		state = &AuthorizationStateWaitRegistration{}
+	case TypeAuthorizationStateWaitPassword:
+		state = &AuthorizationStateWaitPassword{}
+	case TypeAuthorizationStateReady:
+		state = &AuthorizationStateReady{}
+	case TypeAuthorizationStateLoggingOut:
+		state = &AuthorizationStateLoggingOut{}
+	case TypeAuthorizationStateClosing:
+		state = &AuthorizationStateClosing{}
+	case TypeAuthorizationStateClosed:
+		state = &AuthorizationStateClosed{}
+	default:
+		return nil, fmt.Errorf("tdjson: unknown authorization state %q", header.Type)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
@@ -0,0 +1,77 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUnmarshalAuthorizationStateWaitCode(t *testing.T) {
+	data := json.RawMessage(`{"@type":"authorizationStateWaitCode","code_info":{"@type":"authenticationCodeInfo",
+		"phone_number":"+123456789","type":{"@type":"authenticationCodeTypeSms","length":5},
+		"next_type":{"@type":"authenticationCodeTypeCall","length":5},"timeout":60}}`)
+	state, err := UnmarshalAuthorizationState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitCode, ok := state.(*AuthorizationStateWaitCode)
+	if !ok {
+		t.Fatalf("got state %T", state)
+	}
+	if waitCode.AuthorizationStateType() != TypeAuthorizationStateWaitCode {
+		t.Errorf("got type %s", waitCode.AuthorizationStateType())
+	}
+	info := waitCode.CodeInfo
+	if info.Type.Type != "authenticationCodeTypeSms" || info.Type.Length != 5 {
+		t.Errorf("got code type %+v", info.Type)
+	}
+	if info.NextType == nil || info.NextType.Type != "authenticationCodeTypeCall" || info.Timeout != 60 {
+		t.Errorf("got code info %+v", info)
+	}
+}
+
+func TestUnmarshalAuthorizationState(t *testing.T) {
+	tests := []string{
+		`{"@type":"authorizationStateWaitTdlibParameters"}`,
+		`{"@type":"authorizationStateWaitEncryptionKey","is_encrypted":true}`,
+		`{"@type":"authorizationStateWaitPhoneNumber"}`,
+		`{"@type":"authorizationStateWaitOtherDeviceConfirmation","link":"tg://login?token=abc"}`,
+		`{"@type":"authorizationStateWaitRegistration","terms_of_service":{"@type":"termsOfService",
+			"text":{"@type":"formattedText","text":"Terms","entities":[]},"min_user_age":0,"show_popup":true}}`,
+		`{"@type":"authorizationStateWaitPassword","password_hint":"hint","has_recovery_email_address":false,
+			"recovery_email_address_pattern":""}`,
+		`{"@type":"authorizationStateReady"}`,
+		`{"@type":"authorizationStateLoggingOut"}`,
+		`{"@type":"authorizationStateClosing"}`,
+		`{"@type":"authorizationStateClosed"}`,
+	}
+	for _, test := range tests {
+		state, err := UnmarshalAuthorizationState(json.RawMessage(test))
+		if err != nil {
+			t.Errorf("failed to parse %s: %v", test, err)
+			continue
+		}
+		var header struct {
+			Type AuthorizationStateType `json:"@type"`
+		}
+		json.Unmarshal([]byte(test), &header)
+		if state.AuthorizationStateType() != header.Type {
+			t.Errorf("got %s for %s", state.AuthorizationStateType(), test)
+		}
+	}
+
+	state, _ := UnmarshalAuthorizationState(json.RawMessage(tests[4]))
+	if terms := state.(*AuthorizationStateWaitRegistration).TermsOfService; terms.Text.Text != "Terms" || !terms.ShowPopup {
+		t.Errorf("got terms of service %+v", terms)
+	}
+
+	if _, err := UnmarshalAuthorizationState(json.RawMessage(`{"@type":"authorizationStateUnknown"}`)); err == nil {
+		t.Error("unknown state was parsed")
+	}
+}
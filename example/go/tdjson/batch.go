@@ -0,0 +1,89 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+/*
+#include <stdlib.h>
+#include <td/telegram/td_json_client.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// cStringBuffer is a reusable C buffer for null-terminated copies of Go strings.
+type cStringBuffer struct {
+	ptr  unsafe.Pointer
+	size int
+}
+
+// set copies the string to the buffer, growing it if needed. The result is valid until the next call to set or free.
+func (b *cStringBuffer) set(s string) *C.char {
+	if len(s)+1 > b.size {
+		C.free(b.ptr)
+		b.size = 2 * b.size
+		if b.size < len(s)+1 {
+			b.size = len(s) + 1
+		}
+		b.ptr = C.malloc(C.size_t(b.size))
+	}
+	buf := unsafe.Slice((*byte)(b.ptr), len(s)+1)
+	copy(buf, s)
+	buf[len(s)] = 0
+	return (*C.char)(b.ptr)
+}
+
+func (b *cStringBuffer) free() {
+	C.free(b.ptr)
+	b.ptr = nil
+	b.size = 0
+}
+
+func (tdBackend) sendBatch(clientID int, requests []string) {
+	// requests are copied by TDLib before td_send returns, so the same buffer can be used for all of them
+	var buf cStringBuffer
+	defer buf.free()
+	for _, request := range requests {
+		C.td_send(C.int(clientID), buf.set(request))
+	}
+}
+
+// SendBatch sends JSON-serialized requests to TDLib in the given order. It is equivalent to calling Send
+// for each request, but the client lock is taken once and a single C buffer is reused for all requests,
+// so sending of many requests at once is cheaper. May be called from any goroutine.
+func (c *Client) SendBatch(queries []string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.destroyed {
+		return ErrClientDestroyed
+	}
+	if c.validate != nil {
+		// none of the requests is sent if any of them is invalid
+		for _, query := range queries {
+			if err := c.validate([]byte(query)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.manager != nil {
+		if err := c.manager.ctx.Err(); err != nil {
+			return err
+		}
+		c.manager.td.sendBatch(c.clientID, queries)
+		return nil
+	}
+
+	var buf cStringBuffer
+	defer buf.free()
+	for _, query := range queries {
+		C.td_json_client_send(c.client, buf.set(query))
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSendBatch(t *testing.T) {
+	client, td := newFakeClient(t)
+	var got []string
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "addLogMessage" {
+			return
+		}
+		if clientID != client.clientID {
+			t.Errorf("request was sent to client %d", clientID)
+		}
+		got = append(got, request["text"].(string))
+	})
+
+	// requests of different lengths to check that the buffer is reused correctly
+	var queries []string
+	var expected []string
+	for _, text := range []string{"a", strings.Repeat("b", 100), "c", strings.Repeat("d", 1000), ""} {
+		queries = append(queries, fmt.Sprintf(`{"@type":"addLogMessage","verbosity_level":1,"text":%q}`, text))
+		expected = append(expected, text)
+	}
+	if err := client.SendBatch(queries); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("got requests %q", got)
+	}
+
+	client.Destroy()
+	if err := client.SendBatch(queries); err != ErrClientDestroyed {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestSendBatchLegacy(t *testing.T) {
+	client := NewClient()
+	defer client.Destroy()
+	if err := client.SendBatch([]string{`{"@type":"getOption","name":"version"}`, `{"@type":"close"}`}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendBatch(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func batchQueries() []string {
+	queries := make([]string, 500)
+	for i := range queries {
+		queries[i] = fmt.Sprintf(`{"@type":"viewMessages","chat_id":-1001234567890123,"message_thread_id":0,`+
+			`"message_ids":[%d],"force_read":true}`, (i+1)<<20)
+	}
+	return queries
+}
+
+func BenchmarkSend(b *testing.B) {
+	client := NewClient()
+	defer client.Destroy()
+	queries := batchQueries()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, query := range queries {
+			client.Send(query)
+		}
+	}
+}
+
+func BenchmarkSendBatch(b *testing.B) {
+	client := NewClient()
+	defer client.Destroy()
+	queries := batchQueries()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.SendBatch(queries)
+	}
+}
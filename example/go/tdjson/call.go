@@ -0,0 +1,192 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// extraPrefix is the prefix of "@extra" values of requests sent by Call.
+const extraPrefix = "tdjson:"
+
+// pendingCalls contains Call requests waiting for a response, by their "@extra".
+// It is accessed by all goroutines calling Call and by the receive loop.
+type pendingCalls struct {
+	mu      sync.Mutex
+	lastID  uint64
+	waiters map[string]chan string
+	err     error         // set by abort
+	aborted chan struct{} // closed by abort, created on demand
+}
+
+// add registers a new Call. The returned channel receives the response or is closed if the call is aborted.
+func (p *pendingCalls) add() (string, <-chan string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return "", nil, p.err
+	}
+	if p.waiters == nil {
+		p.waiters = make(map[string]chan string)
+	}
+	p.lastID++
+	extra := extraPrefix + strconv.FormatUint(p.lastID, 10)
+	response := make(chan string, 1)
+	p.waiters[extra] = response
+	return extra, response, nil
+}
+
+func (p *pendingCalls) remove(extra string) {
+	p.mu.Lock()
+	delete(p.waiters, extra)
+	p.mu.Unlock()
+}
+
+// complete passes the event to the Call waiting for it and returns true if the event is a response to a Call.
+// Late responses to canceled calls are dropped.
+func (p *pendingCalls) complete(rawExtra json.RawMessage, event string) bool {
+	if len(rawExtra) == 0 || rawExtra[0] != '"' {
+		return false
+	}
+	var extra string
+	if err := json.Unmarshal(rawExtra, &extra); err != nil || !strings.HasPrefix(extra, extraPrefix) {
+		return false
+	}
+
+	p.mu.Lock()
+	response, ok := p.waiters[extra]
+	delete(p.waiters, extra)
+	p.mu.Unlock()
+	if ok {
+		response <- event
+	}
+	return true
+}
+
+// abort wakes up all pending calls, because responses to them will never be received, and makes
+// subsequent calls fail with the error. Only the first error is kept.
+func (p *pendingCalls) abort(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return
+	}
+	p.err = err
+	for extra, response := range p.waiters {
+		close(response)
+		delete(p.waiters, extra)
+	}
+	if p.aborted == nil {
+		p.aborted = make(chan struct{})
+	}
+	close(p.aborted)
+}
+
+// abortedChan returns a channel, which is closed when the calls are aborted.
+func (p *pendingCalls) abortedChan() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.aborted == nil {
+		p.aborted = make(chan struct{})
+	}
+	return p.aborted
+}
+
+func (p *pendingCalls) abortError() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Call sends the request to TDLib and waits for the response to it or for cancellation of the context.
+// The request must not contain "@extra", because it is used to find the response.
+// TDLib error objects are returned as an *Error. May be called simultaneously from any number of goroutines.
+//
+// Responses are matched to requests while events are received from the client, so for clients created by
+// NewClient some goroutine must be calling Receive, for example through a Dispatcher.
+// If the client is closed or destroyed, or its Manager is closed or stopped by its context, pending and subsequent
+// calls fail with ErrClientClosed, ErrClientDestroyed or the context error respectively.
+// Requests sending messages wait for the Limiter of the client before they are sent, see SetLimiter.
+func (c *Client) Call(ctx context.Context, query map[string]interface{}) (json.RawMessage, error) {
+	if err := c.waitLimiter(ctx, query); err != nil {
+		return nil, err
+	}
+
+	extra, response, err := c.calls.add()
+	if err != nil {
+		return nil, err
+	}
+	defer c.calls.remove(extra)
+
+	request := make(map[string]interface{}, len(query)+1)
+	for key, value := range query {
+		request[key] = value
+	}
+	request["@extra"] = extra
+	if err := c.send(request); err != nil {
+		return nil, err
+	}
+
+	select {
+	case result, ok := <-response:
+		if !ok {
+			return nil, c.calls.abortError()
+		}
+		if err := responseError([]byte(result)); err != nil {
+			return nil, err
+		}
+		return json.RawMessage(result), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// send serializes the request to JSON and sends it to TDLib without waiting for the response.
+func (c *Client) send(query map[string]interface{}) error {
+	data, err := json.Marshal(query)
+	if err != nil {
+		return err
+	}
+	return c.Send(string(data))
+}
+
+// sleep is used by CallWithRetry to wait before a retry. It is replaced in tests.
+var sleep = sleepContext
+
+// sleepContext waits for the duration or for cancellation of the context.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CallWithRetry is like Call, but if TDLib rejects the request because of too many requests,
+// it waits for the time specified in the error and repeats the request up to maxRetries times.
+// Other errors are returned immediately.
+func (c *Client) CallWithRetry(ctx context.Context, query map[string]interface{}, maxRetries int) (json.RawMessage, error) {
+	for retry := 0; ; retry++ {
+		result, err := c.Call(ctx, query)
+		retryAfter, isFloodWait := IsFloodWait(err)
+		if !isFloodWait || retry >= maxRetries {
+			return result, err
+		}
+		if err := sleep(ctx, retryAfter); err != nil {
+			return nil, err
+		}
+	}
+}
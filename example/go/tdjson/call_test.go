@@ -0,0 +1,285 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCall(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "getOption":
+			td.push(clientID, map[string]interface{}{"@type": "updateOption", "name": "version"})
+			td.push(clientID, map[string]interface{}{"@type": "optionValueString", "value": "1.7.5", "@extra": request["@extra"]})
+		case "getChat":
+			td.push(clientID, map[string]interface{}{"@type": "error", "code": 400, "message": "Chat not found", "@extra": request["@extra"]})
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.Call(ctx, map[string]interface{}{"@type": "getOption", "name": "version"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var value struct {
+		Type  string `json:"@type"`
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(result, &value); err != nil {
+		t.Fatal(err)
+	}
+	if value.Type != "optionValueString" || value.Value != "1.7.5" {
+		t.Errorf("unexpected response %s", result)
+	}
+
+	// the update isn't a response, so it must be received as usual
+	if event, _ := client.Receive(time.Second); event == "" {
+		t.Error("update wasn't received")
+	}
+
+	_, err = client.Call(ctx, map[string]interface{}{"@type": "getChat", "chat_id": 1})
+	var tdErr *Error
+	if !errors.As(err, &tdErr) || tdErr.Code != 400 || tdErr.Message != "Chat not found" {
+		t.Errorf("got error %v, want TDLib error 400", err)
+	}
+}
+
+func TestCallContextDeadline(t *testing.T) {
+	client, _ := newFakeClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.Call(ctx, map[string]interface{}{"@type": "getMe"}); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(client.calls.waiters) != 0 {
+		t.Error("canceled call is still pending")
+	}
+}
+
+// startPendingCall starts a getMe Call, which never receives a response, and waits until it is sent.
+func startPendingCall(t *testing.T, client *Client, td *fakeBackend, onClose func(clientID int)) <-chan error {
+	sent := make(chan struct{}, 1)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "getMe":
+			sent <- struct{}{}
+		case "close":
+			onClose(clientID)
+		}
+	})
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Call(context.Background(), map[string]interface{}{"@type": "getMe"})
+		done <- err
+	}()
+	<-sent
+	return done
+}
+
+func waitError(t *testing.T, done <-chan error, want error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if err != want {
+			t.Errorf("got error %v, want %v", err, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting wasn't stopped")
+	}
+}
+
+func TestCallClientClosed(t *testing.T) {
+	client, td := newFakeClient(t)
+	done := startPendingCall(t, client, td, func(clientID int) {
+		td.push(clientID, authorizationStateUpdate("authorizationStateClosing"))
+		td.push(clientID, authorizationStateUpdate("authorizationStateClosed"))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitError(t, done, ErrClientClosed)
+	if _, err := client.Call(ctx, map[string]interface{}{"@type": "getMe"}); err != ErrClientClosed {
+		t.Errorf("Call after Close returned %v", err)
+	}
+	if len(client.calls.waiters) != 0 {
+		t.Error("aborted call is still pending")
+	}
+}
+
+func TestCallClientDestroyed(t *testing.T) {
+	client, td := newFakeClient(t)
+	done := startPendingCall(t, client, td, func(int) {})
+
+	client.Destroy()
+	waitError(t, done, ErrClientDestroyed)
+}
+
+// startPendingNext starts waiting for an event of a subscription, which is never received.
+func startPendingNext(client *Client) <-chan error {
+	sub := client.subscribeTypes("updateNewMessage")
+	fileSub := client.subscribeFile(7)
+	done := make(chan error, 2)
+	go func() {
+		defer sub.close()
+		_, err := sub.next(context.Background())
+		done <- err
+	}()
+	go func() {
+		defer fileSub.close()
+		_, err := fileSub.next(context.Background())
+		done <- err
+	}()
+	return done
+}
+
+func TestSubscriptionClientDestroyed(t *testing.T) {
+	client, _ := newFakeClient(t)
+	done := startPendingNext(client)
+
+	client.Destroy()
+	waitError(t, done, ErrClientDestroyed)
+	waitError(t, done, ErrClientDestroyed)
+}
+
+func TestSubscriptionClientClosed(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "close" {
+			td.push(clientID, authorizationStateUpdate("authorizationStateClosed"))
+		}
+	})
+	done := startPendingNext(client)
+
+	// the update itself must still be received by subscriptions
+	sub := client.subscribeTypes("updateAuthorizationState")
+	defer sub.close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	waitError(t, done, ErrClientClosed)
+	waitError(t, done, ErrClientClosed)
+	if _, err := sub.next(ctx); err != nil {
+		t.Errorf("authorizationStateClosed wasn't received: %v", err)
+	}
+	if _, err := sub.next(ctx); err != ErrClientClosed {
+		t.Errorf("got error %v after the last event, want %v", err, ErrClientClosed)
+	}
+}
+
+func TestCallWithRetry(t *testing.T) {
+	client, td := newFakeClient(t)
+	attempts := 0
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		attempts++
+		if attempts == 1 {
+			td.push(clientID, map[string]interface{}{"@type": "error", "code": 429, "message": "Too Many Requests: retry after 3", "@extra": request["@extra"]})
+			return
+		}
+		td.push(clientID, map[string]interface{}{"@type": "ok", "@extra": request["@extra"]})
+	})
+	var sleeps []time.Duration
+	defer func(s func(context.Context, time.Duration) error) { sleep = s }(sleep)
+	sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.CallWithRetry(ctx, map[string]interface{}{"@type": "sendMessage"}, 3); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("request was sent %d times, want 2", attempts)
+	}
+	if len(sleeps) != 1 || sleeps[0] != 3*time.Second {
+		t.Errorf("got sleeps %v, want [3s]", sleeps)
+	}
+}
+
+func TestCallWithRetryLimit(t *testing.T) {
+	client, td := newFakeClient(t)
+	attempts := 0
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		attempts++
+		code, message := 429, "Too Many Requests: retry after 1"
+		if request["@type"] == "getChat" {
+			code, message = 400, "Chat not found"
+		}
+		td.push(clientID, map[string]interface{}{"@type": "error", "code": code, "message": message, "@extra": request["@extra"]})
+	})
+	defer func(s func(context.Context, time.Duration) error) { sleep = s }(sleep)
+	sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.CallWithRetry(ctx, map[string]interface{}{"@type": "sendMessage"}, 2); err == nil {
+		t.Fatal("got no error")
+	}
+	if attempts != 3 {
+		t.Errorf("request was sent %d times, want 3", attempts)
+	}
+
+	attempts = 0
+	_, err := client.CallWithRetry(ctx, map[string]interface{}{"@type": "getChat"}, 2)
+	var tdErr *Error
+	if !errors.As(err, &tdErr) || tdErr.Code != 400 || attempts != 1 {
+		t.Errorf("got error %v after %d attempts, want error 400 after 1 attempt", err, attempts)
+	}
+}
+
+func TestCallConcurrent(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "testCallNumber" {
+			td.push(clientID, map[string]interface{}{"@type": "testInt", "value": request["x"], "@extra": request["@extra"]})
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const callCount = 1000
+	errs := make(chan error, callCount)
+	for i := 0; i < callCount; i++ {
+		go func(x int) {
+			result, err := client.Call(ctx, map[string]interface{}{"@type": "testCallNumber", "x": x})
+			if err == nil {
+				var value struct {
+					Value int `json:"value"`
+				}
+				err = json.Unmarshal(result, &value)
+				if err == nil && value.Value != x {
+					err = fmt.Errorf("call %d got response %d", x, value.Value)
+				}
+			}
+			errs <- err
+		}(i)
+	}
+	for i := 0; i < callCount; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	if len(client.calls.waiters) != 0 {
+		t.Errorf("%d calls are still pending", len(client.calls.waiters))
+	}
+}
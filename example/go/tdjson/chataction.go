@@ -0,0 +1,89 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Types of chat actions, which can be passed to StartChatAction.
+const (
+	ChatActionTyping             = "chatActionTyping"
+	ChatActionRecordingVideo     = "chatActionRecordingVideo"
+	ChatActionUploadingVideo     = "chatActionUploadingVideo"
+	ChatActionRecordingVoiceNote = "chatActionRecordingVoiceNote"
+	ChatActionUploadingVoiceNote = "chatActionUploadingVoiceNote"
+	ChatActionUploadingPhoto     = "chatActionUploadingPhoto"
+	ChatActionUploadingDocument  = "chatActionUploadingDocument"
+	ChatActionChoosingLocation   = "chatActionChoosingLocation"
+	ChatActionChoosingContact    = "chatActionChoosingContact"
+	ChatActionStartPlayingGame   = "chatActionStartPlayingGame"
+	ChatActionRecordingVideoNote = "chatActionRecordingVideoNote"
+	ChatActionUploadingVideoNote = "chatActionUploadingVideoNote"
+	ChatActionCancel             = "chatActionCancel"
+)
+
+// chatActionInterval is the interval between repeated sendChatAction requests. Chat actions are shown
+// by other applications for 5 seconds after they are received. It is replaced in tests.
+var chatActionInterval = 4 * time.Second
+
+// StartChatAction shows the chat action, for example ChatActionTyping, in the chat until stop is called.
+// The action is sent immediately and then resent periodically in the background, because it expires
+// otherwise. Upload progress of ChatActionUploading* actions is sent as 0.
+//
+// When stop is called, the action is canceled by sending ChatActionCancel. The action isn't resent anymore
+// if the client is destroyed or closed. Stop may be called more than once.
+func (c *Client) StartChatAction(chatID int64, action string) (stop func()) {
+	interval := chatActionInterval
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			err := c.sendChatAction(ctx, chatID, action)
+			var tdErr *Error
+			if err != nil && !errors.As(err, &tdErr) {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+			// the response isn't waited for, so stop doesn't block
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				defer cancel()
+				_ = c.sendChatAction(ctx, chatID, ChatActionCancel)
+			}()
+		})
+	}
+}
+
+func (c *Client) sendChatAction(ctx context.Context, chatID int64, action string) error {
+	_, err := c.Call(ctx, map[string]interface{}{
+		"@type":             "sendChatAction",
+		"chat_id":           chatID,
+		"message_thread_id": 0,
+		"action":            map[string]interface{}{"@type": action},
+	})
+	return err
+}
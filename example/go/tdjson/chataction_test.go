@@ -0,0 +1,97 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartChatAction(t *testing.T) {
+	defer func(interval time.Duration) { chatActionInterval = interval }(chatActionInterval)
+	chatActionInterval = 50 * time.Millisecond
+
+	client, td := newFakeClient(t)
+	type action struct {
+		typ  string
+		time time.Time
+	}
+	actions := make(chan action, 100)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "sendChatAction" {
+			return
+		}
+		if request["chat_id"] != float64(testChatID) || request["message_thread_id"] != float64(0) {
+			t.Errorf("got request %v", request)
+		}
+		actions <- action{request["action"].(map[string]interface{})["@type"].(string), time.Now()}
+		td.push(clientID, map[string]interface{}{"@type": "ok", "@extra": request["@extra"]})
+	})
+
+	start := time.Now()
+	stop := client.StartChatAction(testChatID, ChatActionTyping)
+	var times []time.Time
+	for len(times) < 4 {
+		select {
+		case a := <-actions:
+			if a.typ != ChatActionTyping {
+				t.Fatalf("got action %s", a.typ)
+			}
+			times = append(times, a.time)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("action was sent %d times", len(times))
+		}
+	}
+	stop()
+	stopped := time.Now()
+
+	if times[0].Sub(start) > chatActionInterval/2 {
+		t.Errorf("the first action was sent after %v", times[0].Sub(start))
+	}
+	for i := 1; i < len(times); i++ {
+		// ticks can be delayed, but not advanced
+		if d := times[i].Sub(times[i-1]); d < chatActionInterval/2 {
+			t.Errorf("action was resent after %v", d)
+		}
+	}
+	if d := stopped.Sub(times[len(times)-1]); d > chatActionInterval {
+		t.Errorf("stop took %v", d)
+	}
+
+	select {
+	case a := <-actions:
+		if a.typ != ChatActionCancel {
+			t.Fatalf("got action %s instead of cancel", a.typ)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("action wasn't canceled")
+	}
+	stop()
+	select {
+	case a := <-actions:
+		t.Errorf("got action %s after stop", a.typ)
+	case <-time.After(3 * chatActionInterval):
+	}
+}
+
+func TestStartChatActionDestroyed(t *testing.T) {
+	client, _ := newFakeClient(t)
+	client.Destroy()
+
+	stop := client.StartChatAction(testChatID, ChatActionUploadingDocument)
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop didn't return")
+	}
+}
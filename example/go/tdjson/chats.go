@@ -0,0 +1,105 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// chatsPageSize is the number of chats requested by one getChats request.
+const chatsPageSize = 100
+
+// ChatList represents any of chatList* objects. Fields, which aren't used by the type, are empty.
+type ChatList struct {
+	Type         string `json:"@type"`
+	ChatFilterID int32  `json:"chat_filter_id,omitempty"` // chatListFilter
+}
+
+// Chat lists, which exist for every user.
+var (
+	ChatListMain    = ChatList{Type: "chatListMain"}
+	ChatListArchive = ChatList{Type: "chatListArchive"}
+)
+
+// ChatListFilter returns the list of chats belonging to the chat filter with the given identifier.
+func ChatListFilter(chatFilterID int32) ChatList {
+	return ChatList{Type: "chatListFilter", ChatFilterID: chatFilterID}
+}
+
+// LoadAllChats requests chats of the chat list page by page with getChats until the end of the list is reached
+// and returns their identifiers in the order of the chat list. Chats moved in the list while they are being
+// loaded may be missing from the result or returned at their old position.
+func (c *Client) LoadAllChats(ctx context.Context, list ChatList) ([]int64, error) {
+	var chatIDs []int64
+	seen := make(map[int64]bool)
+	offsetOrder := int64(math.MaxInt64)
+	offsetChatID := int64(0)
+	for {
+		result, err := c.Call(ctx, map[string]interface{}{
+			"@type":     "getChats",
+			"chat_list": list,
+			// int64 values are serialized as strings
+			"offset_order":   strconv.FormatInt(offsetOrder, 10),
+			"offset_chat_id": offsetChatID,
+			"limit":          chatsPageSize,
+		})
+		if err != nil {
+			return chatIDs, err
+		}
+		var chats struct {
+			ChatIDs []int64 `json:"chat_ids"`
+		}
+		if err := json.Unmarshal(result, &chats); err != nil {
+			return chatIDs, err
+		}
+		// an empty page is returned after the end of the list
+		if len(chats.ChatIDs) == 0 {
+			return chatIDs, nil
+		}
+		for _, chatID := range chats.ChatIDs {
+			if !seen[chatID] {
+				seen[chatID] = true
+				chatIDs = append(chatIDs, chatID)
+			}
+		}
+
+		// the next page starts after the last returned chat
+		offsetChatID = chats.ChatIDs[len(chats.ChatIDs)-1]
+		offsetOrder, err = c.chatOrder(ctx, offsetChatID, list)
+		if err != nil {
+			return chatIDs, err
+		}
+	}
+}
+
+// chatOrder returns the order of the chat in the chat list.
+func (c *Client) chatOrder(ctx context.Context, chatID int64, list ChatList) (int64, error) {
+	result, err := c.Call(ctx, map[string]interface{}{"@type": "getChat", "chat_id": chatID})
+	if err != nil {
+		return 0, err
+	}
+	var chat struct {
+		Positions []struct {
+			List  ChatList `json:"list"`
+			Order int64    `json:"order,string"`
+		} `json:"positions"`
+	}
+	if err := json.Unmarshal(result, &chat); err != nil {
+		return 0, err
+	}
+	for _, position := range chat.Positions {
+		if position.List == list {
+			return position.Order, nil
+		}
+	}
+	return 0, fmt.Errorf("tdjson: chat %d was removed from the chat list while it was being loaded", chatID)
+}
@@ -0,0 +1,113 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serveChatList makes the backend return chats of the list by pages of the given sizes.
+// Chats are ordered by decreasing order, which is 1000 times the position from the end of the list.
+func serveChatList(t *testing.T, td *fakeBackend, list ChatList, chatIDs []int64, pageSizes []int) *[]map[string]interface{} {
+	orders := make(map[int64]int64)
+	for i, chatID := range chatIDs {
+		orders[chatID] = int64(len(chatIDs)-i) * 1000
+	}
+	var requests []map[string]interface{}
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "getChats":
+			requests = append(requests, request)
+			data, _ := json.Marshal(request["chat_list"])
+			var requestList ChatList
+			if err := json.Unmarshal(data, &requestList); err != nil || requestList != list {
+				t.Errorf("got chat list %s", data)
+			}
+			offsetOrder, err := strconv.ParseInt(request["offset_order"].(string), 10, 64)
+			if err != nil {
+				t.Errorf("got offset_order %v", request["offset_order"])
+			}
+			page := []int64{}
+			if len(pageSizes) > 0 {
+				for _, chatID := range chatIDs {
+					if orders[chatID] < offsetOrder && len(page) < pageSizes[0] {
+						page = append(page, chatID)
+					}
+				}
+				pageSizes = pageSizes[1:]
+			}
+			td.push(clientID, map[string]interface{}{"@type": "chats", "total_count": len(chatIDs), "chat_ids": page,
+				"@extra": request["@extra"]})
+		case "getChat":
+			chatID := int64(request["chat_id"].(float64))
+			td.push(clientID, map[string]interface{}{"@type": "chat", "id": chatID, "positions": []interface{}{
+				map[string]interface{}{"@type": "chatPosition", "list": ChatListArchive, "order": "1"},
+				map[string]interface{}{"@type": "chatPosition", "list": list, "order": strconv.FormatInt(orders[chatID], 10)},
+			}, "@extra": request["@extra"]})
+		}
+	})
+	return &requests
+}
+
+func TestLoadAllChats(t *testing.T) {
+	client, td := newFakeClient(t)
+	chatIDs := []int64{5, testChatID, 3, 100, -200}
+	requests := serveChatList(t, td, ChatListMain, chatIDs, []int{3, 2})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got, err := client.LoadAllChats(ctx, ChatListMain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, chatIDs) {
+		t.Errorf("got chats %v, want %v", got, chatIDs)
+	}
+	if len(*requests) != 3 {
+		t.Fatalf("got %d requests", len(*requests))
+	}
+	offsets := [][2]interface{}{{"9223372036854775807", 0.0}, {"3000", 3.0}, {"1000", -200.0}}
+	for i, request := range *requests {
+		if request["offset_order"] != offsets[i][0] || request["offset_chat_id"] != offsets[i][1] {
+			t.Errorf("request %d has offset %v, %v", i, request["offset_order"], request["offset_chat_id"])
+		}
+	}
+}
+
+func TestLoadAllChatsFilter(t *testing.T) {
+	client, td := newFakeClient(t)
+	chatIDs := []int64{7, 8}
+	serveChatList(t, td, ChatListFilter(3), chatIDs, []int{1, 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got, err := client.LoadAllChats(ctx, ChatListFilter(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, chatIDs) {
+		t.Errorf("got chats %v, want %v", got, chatIDs)
+	}
+}
+
+func TestChatListJSON(t *testing.T) {
+	for list, want := range map[ChatList]string{
+		ChatListMain:       `{"@type":"chatListMain"}`,
+		ChatListArchive:    `{"@type":"chatListArchive"}`,
+		ChatListFilter(12): `{"@type":"chatListFilter","chat_filter_id":12}`,
+	} {
+		if data, err := json.Marshal(list); err != nil || string(data) != want {
+			t.Errorf("got %s, want %s", data, want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+/*
+#include <stdlib.h>
+#include <td/telegram/td_json_client.h>
+*/
+import "C"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// ErrClientDestroyed is returned by methods of a Client, which was already destroyed.
+var ErrClientDestroyed = errors.New("tdjson: client is destroyed")
+
+// Client is a TDLib client instance.
+// All methods of a Client are safe for concurrent use. Send and Call can be called simultaneously from any number
+// of goroutines, because TDLib allows sending requests from any thread, while simultaneous calls of Receive
+// are serialized, because TDLib doesn't allow receiving events from different threads at the same time.
+//
+// A Client is created either by NewClient, which uses the old td_json_client_create interface,
+// or by Manager.NewClient, which uses the new td_create_client_id interface and should be preferred.
+type Client struct {
+	mu        sync.RWMutex // held for writing only by Destroy
+	recvMu    sync.Mutex   // td_json_client_receive must not be called simultaneously from different threads
+	destroyed bool
+	done      chan struct{} // closed when the client is destroyed
+
+	closeOnce sync.Once
+	closed    chan struct{} // closed when authorizationStateClosed is received
+
+	client unsafe.Pointer // instance created by td_json_client_create, nil for clients of a Manager
+
+	validate func(request []byte) error // set by SetRequestValidator
+	limiter  Limiter                    // set by SetLimiter
+
+	manager  *Manager
+	clientID int
+	events   *eventQueue // events routed to the client by the manager
+
+	calls      pendingCalls
+	subs       subscriptions
+	files      fileSubscriptions
+	connection connectionState
+	stats      clientStats
+	me         meCache
+}
+
+// eventHeader contains the fields of received events, which are used by the package itself.
+type eventHeader struct {
+	Type     string          `json:"@type"`
+	ClientID int             `json:"@client_id"`
+	Extra    json.RawMessage `json:"@extra"`
+}
+
+// eventBytes returns the bytes of the event without copying them. encoding/json neither modifies
+// nor retains its input, so the result can be decoded, but must never be modified.
+func eventBytes(event string) []byte {
+	return unsafe.Slice(unsafe.StringData(event), len(event))
+}
+
+func parseEventHeader(event string) (eventHeader, bool) {
+	var header eventHeader
+	if err := json.Unmarshal(eventBytes(event), &header); err != nil {
+		return header, false
+	}
+	return header, true
+}
+
+// NewClient creates a new instance of TDLib using the old td_json_client_create interface.
+func NewClient() *Client {
+	return &Client{
+		client: C.td_json_client_create(),
+		done:   make(chan struct{}),
+		closed: make(chan struct{}),
+		me:     newMeCache(),
+	}
+}
+
+// Send sends a JSON-serialized request to TDLib. May be called from any goroutine.
+func (c *Client) Send(query string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.destroyed {
+		return ErrClientDestroyed
+	}
+	if c.validate != nil {
+		if err := c.validate([]byte(query)); err != nil {
+			return err
+		}
+	}
+
+	if c.manager != nil {
+		if err := c.manager.ctx.Err(); err != nil {
+			return err
+		}
+		c.manager.td.send(c.clientID, query)
+		return nil
+	}
+
+	// the request is copied by TDLib before td_json_client_send returns, so it can be freed immediately
+	cQuery := C.CString(query)
+	defer C.free(unsafe.Pointer(cQuery))
+	C.td_json_client_send(c.client, cQuery)
+	return nil
+}
+
+// SetRequestValidator makes Send, SendBatch and all methods sending requests check each request with the function
+// before it is sent and return the error instead of sending the request. Passing tdapi.ValidateRequest enables
+// checking of requests against the TDLib schema, which reports misspelled types and fields that TDLib silently
+// ignores or rejects only when the request is handled. The checks are slow, so they are meant for debugging.
+// Passing nil disables the checks.
+func (c *Client) SetRequestValidator(validate func(request []byte) error) {
+	c.mu.Lock()
+	c.validate = validate
+	c.mu.Unlock()
+}
+
+// Receive waits up to timeout for a new incoming update or a response to a request and returns it serialized to JSON.
+// An empty string is returned if the timeout expires. Responses to requests sent by Call aren't returned.
+// Events of clients of a Manager are queued until they are received, so the events must be received continuously.
+func (c *Client) Receive(timeout time.Duration) (string, error) {
+	c.recvMu.Lock()
+	defer c.recvMu.Unlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.destroyed {
+		return "", ErrClientDestroyed
+	}
+
+	if c.manager != nil {
+		if err := c.manager.ctx.Err(); err != nil {
+			return "", err
+		}
+		event, _ := c.events.pop(timeout)
+		return event, nil
+	}
+
+	runtime.LockOSThread() // see Execute
+	defer runtime.UnlockOSThread()
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining < 0 {
+			remaining = 0
+		}
+		event, ok := c.receive(remaining.Seconds())
+		if !ok {
+			return "", nil
+		}
+		if header, ok := parseEventHeader(event); !ok || !c.process(event, &header) {
+			return event, nil
+		}
+	}
+}
+
+// receive waits for an event using td_json_client_receive. The second returned value is false if no event
+// was received before the timeout expired, in which case TDLib returns a null pointer instead of an event.
+// Must be called from a goroutine locked to its thread, see Execute.
+func (c *Client) receive(timeout float64) (string, bool) {
+	result := C.td_json_client_receive(c.client, C.double(timeout))
+	if result == nil {
+		return "", false
+	}
+	return C.GoString(result), true
+}
+
+// process handles the event internally and returns true if it must not be returned by Receive.
+// It is called for all events received by the client in the order in which they were received.
+func (c *Client) process(event string, header *eventHeader) bool {
+	c.stats.record(header.Type)
+	switch header.Type {
+	case "updateConnectionState":
+		c.connection.update(event)
+	case "updateOption":
+		recordVersion(event)
+		c.me.updateOption(event)
+	case "updateUser":
+		c.me.updateUser(event)
+	case "updateFile":
+		c.files.publish(event)
+	case "updateAuthorizationState":
+		state := authorizationStateType(event)
+		if state != TypeAuthorizationStateReady {
+			c.me.reset()
+		}
+		if state == TypeAuthorizationStateClosed {
+			c.closeOnce.Do(func() { close(c.closed) })
+			// subscriptions receive the update before waiting for further events fails
+			defer c.calls.abort(ErrClientClosed)
+		}
+	}
+	c.subs.publish(event, header)
+	return c.calls.complete(header.Extra, event)
+}
+
+// Close closes the TDLib instance, waiting until all pending database writes are flushed, and destroys it.
+// If the context is done before the instance is closed, the client is destroyed anyway and the error is returned.
+//
+// The instance is closed after authorizationStateClosed is received, so for clients created by NewClient
+// some goroutine must be calling Receive.
+func (c *Client) Close(ctx context.Context) error {
+	defer c.Destroy()
+	select {
+	case <-c.closed:
+		return nil
+	default:
+	}
+
+	if err := c.Send(`{"@type":"close"}`); err != nil {
+		return err
+	}
+	select {
+	case <-c.closed:
+		return nil
+	case <-c.done:
+		return ErrClientDestroyed
+	case <-c.calls.abortedChan():
+		select {
+		case <-c.closed:
+			return nil
+		default:
+		}
+		return c.calls.abortError()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Destroy destroys the TDLib instance. Subsequent calls of the client methods return ErrClientDestroyed.
+// Use Close to ensure that all pending database writes are flushed before the instance is destroyed.
+//
+// Clients of a Manager can't be destroyed directly, so unless they are already closed, they are closed
+// and TDLib destroys them automatically afterwards.
+func (c *Client) Destroy() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.destroyed {
+		return
+	}
+	c.destroyed = true
+	close(c.done)
+	c.calls.abort(ErrClientDestroyed)
+
+	if c.manager != nil {
+		select {
+		case <-c.closed:
+		default:
+			c.manager.td.send(c.clientID, `{"@type":"close"}`)
+		}
+		c.manager.removeClient(c.clientID)
+		return
+	}
+
+	C.td_json_client_destroy(c.client)
+	c.client = nil
+}
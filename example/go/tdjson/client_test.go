@@ -0,0 +1,167 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tdlib/td/example/go/tdapi"
+)
+
+// residentSetSize returns the resident set size of the process in bytes.
+func residentSetSize(t *testing.T) int64 {
+	statm, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(statm))
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pages * int64(os.Getpagesize())
+}
+
+func TestSendDoesNotLeak(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resident set size is read from /proc")
+	}
+
+	client := NewClient()
+	defer client.Destroy()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if _, err := client.Receive(10 * time.Millisecond); err != nil {
+				return
+			}
+		}
+	}()
+
+	const requestCount = 10000
+	const paddingSize = 4 << 10
+	request := `{"@type":"getOption","name":"version","padding":"` + strings.Repeat("a", paddingSize) + `"}`
+	for i := 0; i < requestCount/10; i++ {
+		client.Send(request)
+	}
+	runtime.GC()
+	before := residentSetSize(t)
+
+	for i := 0; i < requestCount; i++ {
+		if err := client.Send(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runtime.GC()
+	growth := residentSetSize(t) - before
+
+	// leaking every request would take requestCount * paddingSize = 40 MB
+	if growth > 16<<20 {
+		t.Errorf("resident set size grew by %d bytes after %d requests", growth, requestCount)
+	}
+}
+
+func TestClose(t *testing.T) {
+	client, td := newFakeClient(t)
+	closeRequests := make(chan struct{}, 10)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "close" {
+			closeRequests <- struct{}{}
+			td.push(clientID, authorizationStateUpdate("authorizationStateClosing"))
+		}
+	})
+
+	closed := make(chan error, 1)
+	go func() { closed <- client.Close(context.Background()) }()
+	<-closeRequests
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case err := <-closed:
+		t.Fatalf("Close returned %v before the client was closed", err)
+	default:
+	}
+	if err := client.Send(`{"@type":"getOption","name":"version"}`); err != nil {
+		t.Fatalf("client was destroyed before it was closed: %v", err)
+	}
+
+	td.push(client.clientID, authorizationStateUpdate("authorizationStateClosed"))
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Send(`{"@type":"getOption","name":"version"}`); err != ErrClientDestroyed {
+		t.Errorf("got %v after Close, want %v", err, ErrClientDestroyed)
+	}
+	if len(closeRequests) != 0 {
+		t.Error("close was sent again after the client was closed")
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	client, _ := newFakeClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := client.Close(ctx); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := client.Send(`{"@type":"getOption","name":"version"}`); err != ErrClientDestroyed {
+		t.Errorf("got %v after Close, want %v", err, ErrClientDestroyed)
+	}
+}
+
+func TestRequestValidator(t *testing.T) {
+	client, td := newFakeClient(t)
+	sent := make(chan map[string]interface{}, 10)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "close" {
+			sent <- request
+		}
+	})
+	client.SetRequestValidator(tdapi.ValidateRequest)
+
+	err := client.Send(`{"@type":"sendMesage","chat_id":1}`)
+	if err == nil || err.Error() != "tdapi: sendMesage: unknown function" {
+		t.Errorf("got error %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = client.Call(ctx, map[string]interface{}{"@type": "getChat", "chatid": 1})
+	if err == nil || err.Error() != "tdapi: getChat.chatid: unknown field of getChat" {
+		t.Errorf("got error %v", err)
+	}
+	if err := client.SendBatch([]string{`{"@type":"getMe"}`, `{"@type":"getMe","extra":1}`}); err == nil {
+		t.Error("invalid batch was sent")
+	}
+	if len(sent) != 0 {
+		t.Fatalf("invalid request was sent: %v", <-sent)
+	}
+
+	if err := client.Send(`{"@type":"getChat","chat_id":1}`); err != nil {
+		t.Fatal(err)
+	}
+	client.SetRequestValidator(nil)
+	if err := client.Send(`{"@type":"sendMesage"}`); err != nil {
+		t.Fatal(err)
+	}
+	if first, second := <-sent, <-sent; first["@type"] != "getChat" || second["@type"] != "sendMesage" {
+		t.Errorf("got requests %v and %v", first, second)
+	}
+}
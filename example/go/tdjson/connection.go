@@ -0,0 +1,120 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// ConnectionStateReady is the connection state in which TDLib is connected to Telegram and up to date.
+const ConnectionStateReady = "connectionStateReady"
+
+// connectionState tracks the last connection state received in updateConnectionState.
+type connectionState struct {
+	mu      sync.Mutex
+	state   string
+	changed chan struct{} // created on demand and closed when the state changes
+}
+
+func (s *connectionState) update(event string) {
+	state := parseConnectionState(event)
+	if state == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+}
+
+// parseConnectionState returns the "@type" of the state in updateConnectionState.
+func parseConnectionState(event string) string {
+	var update struct {
+		State struct {
+			Type string `json:"@type"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal([]byte(event), &update); err != nil {
+		return ""
+	}
+	return update.State.Type
+}
+
+// get returns the current state and a channel, which is closed when the state changes.
+func (s *connectionState) get() (string, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.changed == nil {
+		s.changed = make(chan struct{})
+	}
+	return s.state, s.changed
+}
+
+// ConnectionState returns a channel receiving the "@type" of the current connection state, if it is already known,
+// and of every subsequent connection state of the client, for example "connectionStateConnecting" or
+// ConnectionStateReady. The channel is closed when the client is destroyed or closed and must be read until then.
+func (c *Client) ConnectionState() <-chan string {
+	sub := c.subscribeTypes("updateConnectionState")
+	current, _ := c.connection.get()
+
+	states := make(chan string)
+	go func() {
+		defer close(states)
+		defer sub.close()
+		ctx, cancel := contextWithDone(c.done)
+		defer cancel()
+
+		sent := ""
+		state := current
+		for {
+			if state != "" && state != sent {
+				select {
+				case states <- state:
+				case <-c.done:
+					return
+				case <-c.calls.abortedChan():
+					return
+				}
+				sent = state
+			}
+
+			event, err := sub.next(ctx)
+			if err != nil {
+				return
+			}
+			state = parseConnectionState(event)
+		}
+	}()
+	return states
+}
+
+// WaitForReady waits until the connection state of the client becomes ConnectionStateReady.
+// It returns ErrClientClosed if the client is closed before that.
+func (c *Client) WaitForReady(ctx context.Context) error {
+	for {
+		state, changed := c.connection.get()
+		if state == ConnectionStateReady {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-c.done:
+			return ErrClientDestroyed
+		case <-c.calls.abortedChan():
+			return c.calls.abortError()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
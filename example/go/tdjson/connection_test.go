@@ -0,0 +1,97 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func connectionStateUpdate(state string) map[string]interface{} {
+	return map[string]interface{}{"@type": "updateConnectionState", "state": map[string]interface{}{"@type": state}}
+}
+
+func TestWaitForReady(t *testing.T) {
+	client, td := newFakeClient(t)
+	states := client.ConnectionState()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ready := make(chan error, 2)
+	go func() { ready <- client.WaitForReady(ctx) }()
+
+	var got []string
+	for _, state := range []string{"connectionStateConnecting", "connectionStateUpdating"} {
+		td.push(client.clientID, connectionStateUpdate(state))
+		got = append(got, <-states)
+		select {
+		case <-ready:
+			t.Fatalf("WaitForReady returned in state %s", state)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	td.push(client.clientID, connectionStateUpdate(ConnectionStateReady))
+	got = append(got, <-states)
+	if err := <-ready; err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ready:
+		t.Fatal("WaitForReady returned twice")
+	default:
+	}
+	want := []string{"connectionStateConnecting", "connectionStateUpdating", ConnectionStateReady}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got states %v, want %v", got, want)
+	}
+
+	// the state is already ready
+	if err := client.WaitForReady(ctx); err != nil {
+		t.Error(err)
+	}
+
+	client.Destroy()
+	if _, ok := <-states; ok {
+		t.Error("state channel isn't closed after the client is destroyed")
+	}
+}
+
+func TestWaitForReadyClientClosed(t *testing.T) {
+	client, td := newFakeClient(t)
+	states := client.ConnectionState()
+	done := make(chan error, 1)
+	go func() { done <- client.WaitForReady(context.Background()) }()
+
+	// the state isn't read, so the goroutine sending states must be stopped by closing
+	td.push(client.clientID, connectionStateUpdate("connectionStateConnecting"))
+	td.push(client.clientID, authorizationStateUpdate("authorizationStateClosed"))
+	waitError(t, done, ErrClientClosed)
+	timeout := time.After(5 * time.Second)
+	for ok := true; ok; {
+		select {
+		case _, ok = <-states:
+		case <-timeout:
+			t.Fatal("state channel isn't closed after the client is closed")
+		}
+	}
+}
+
+func TestWaitForReadyManagerClosed(t *testing.T) {
+	td := newFakeBackend()
+	m := newManager(context.Background(), td)
+	client := m.NewClient()
+	defer client.Destroy()
+	done := make(chan error, 1)
+	go func() { done <- client.WaitForReady(context.Background()) }()
+
+	m.Close()
+	waitError(t, done, ErrClientClosed)
+}
@@ -0,0 +1,26 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+)
+
+// ChangeDatabaseKey re-encrypts the local database with the new key and waits until it is done.
+// The database can be re-encrypted only after it was opened with the current key, i.e. after the authorization state
+// authorizationStateWaitEncryptionKey was left; otherwise TDLib returns an error.
+// An empty key disables the encryption. The new key must be passed in checkDatabaseEncryptionKey afterwards,
+// for example in TdlibParameters.DatabaseEncryptionKey, to open the database next time.
+func (c *Client) ChangeDatabaseKey(ctx context.Context, newKey []byte) error {
+	if newKey == nil {
+		// nil would be serialized as null instead of an empty string
+		newKey = []byte{}
+	}
+	_, err := c.Call(ctx, map[string]interface{}{"@type": "setDatabaseEncryptionKey", "new_encryption_key": newKey})
+	return err
+}
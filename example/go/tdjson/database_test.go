@@ -0,0 +1,57 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChangeDatabaseKey(t *testing.T) {
+	client, td := newFakeClient(t)
+	keys := make(chan interface{}, 2)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "setDatabaseEncryptionKey" {
+			return
+		}
+		keys <- request["new_encryption_key"]
+		td.push(clientID, map[string]interface{}{"@type": "ok", "@extra": request["@extra"]})
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.ChangeDatabaseKey(ctx, []byte{0, 1, 0xfe, 0xff, 'k'}); err != nil {
+		t.Fatal(err)
+	}
+	if key := <-keys; key != "AAH+/2s=" {
+		t.Errorf("got key %v", key)
+	}
+	if err := client.ChangeDatabaseKey(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if key := <-keys; key != "" {
+		t.Errorf("got key %v", key)
+	}
+}
+
+func TestChangeDatabaseKeyError(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "setDatabaseEncryptionKey" {
+			td.push(clientID, map[string]interface{}{"@type": "error", "code": 400, "message": "Database is not opened", "@extra": request["@extra"]})
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := client.ChangeDatabaseKey(ctx, []byte("key"))
+	if tdErr, ok := err.(*Error); !ok || tdErr.Code != 400 {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// dispatcherReceiveTimeout is the maximum time for which Dispatcher.Run waits for a new event,
+// and hence the maximum delay between cancellation of its context and return of Run.
+const dispatcherReceiveTimeout = 100 * time.Millisecond
+
+// Dispatcher receives events from a Client and calls handlers registered for their "@type".
+//
+// Handlers are called on a pool of worker goroutines, so a slow handler doesn't block receiving of events.
+// Events related to the same chat are handled by the same worker in the order in which they were received.
+// All other events, for example updateAuthorizationState, are handled in order by the first worker.
+type Dispatcher struct {
+	client *Client
+
+	mu        sync.RWMutex
+	handlers  map[string][]func(json.RawMessage)
+	unhandled func(json.RawMessage)
+	onPanic   func(recovered interface{}, stack []byte)
+
+	workers []*eventQueue
+}
+
+// NewDispatcher creates a Dispatcher for the client with the given number of worker goroutines.
+func NewDispatcher(client *Client, workerCount int) *Dispatcher {
+	if workerCount < 1 {
+		workerCount = 1
+	}
+	d := &Dispatcher{
+		client:   client,
+		handlers: make(map[string][]func(json.RawMessage)),
+		workers:  make([]*eventQueue, workerCount),
+	}
+	for i := range d.workers {
+		d.workers[i] = newEventQueue()
+	}
+	return d
+}
+
+// OnUpdate registers a handler for objects with the given "@type". Several handlers can be registered for
+// the same type; they are called in the order of registration. The handler receives the object exactly as it
+// was sent by TDLib; use Decode to deserialize it to generic maps without losing precision of identifiers.
+//
+// The object shares memory with the received event, so it must not be modified. Handlers, which need to
+// modify it, must copy it.
+func (d *Dispatcher) OnUpdate(typ string, fn func(json.RawMessage)) {
+	d.mu.Lock()
+	d.handlers[typ] = append(d.handlers[typ], fn)
+	d.mu.Unlock()
+}
+
+// OnUnhandled registers a handler for objects, for which there are no handlers registered with OnUpdate.
+// As for OnUpdate, the object must not be modified.
+func (d *Dispatcher) OnUnhandled(fn func(json.RawMessage)) {
+	d.mu.Lock()
+	d.unhandled = fn
+	d.mu.Unlock()
+}
+
+// OnPanic registers a function, which is called with the recovered value and the stack trace if a handler panics.
+// Panics in handlers are logged and don't stop dispatching of subsequent events in any case.
+func (d *Dispatcher) OnPanic(fn func(recovered interface{}, stack []byte)) {
+	d.mu.Lock()
+	d.onPanic = fn
+	d.mu.Unlock()
+}
+
+// Run receives events from the client and dispatches them until the context is canceled or the client is destroyed.
+// Events which were already received are handled before Run returns.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, worker := range d.workers {
+		wg.Add(1)
+		go func(worker *eventQueue) {
+			defer wg.Done()
+			d.work(worker, stop)
+		}(worker)
+	}
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		event, err := d.client.Receive(dispatcherReceiveTimeout)
+		if err != nil {
+			return err
+		}
+		if event != "" {
+			d.workers[d.workerIndex(event)].push(event)
+		}
+	}
+}
+
+func (d *Dispatcher) work(worker *eventQueue, stop <-chan struct{}) {
+	for {
+		event, ok := worker.popUntil(stop)
+		if !ok {
+			return
+		}
+
+		d.handle(json.RawMessage(eventBytes(event)))
+	}
+}
+
+func (d *Dispatcher) handle(event json.RawMessage) {
+	var header struct {
+		Type string `json:"@type"`
+	}
+	if err := json.Unmarshal(event, &header); err != nil {
+		return
+	}
+
+	d.mu.RLock()
+	handlers := d.handlers[header.Type]
+	unhandled := d.unhandled
+	onPanic := d.onPanic
+	d.mu.RUnlock()
+
+	if len(handlers) == 0 {
+		if unhandled != nil {
+			d.call(unhandled, event, header.Type, onPanic)
+		}
+		return
+	}
+	for _, fn := range handlers {
+		d.call(fn, event, header.Type, onPanic)
+	}
+}
+
+// call calls the handler, recovering from a panic in it.
+func (d *Dispatcher) call(fn func(json.RawMessage), event json.RawMessage, typ string, onPanic func(interface{}, []byte)) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			stack := debug.Stack()
+			log.Printf("tdjson: handler of %s panicked: %v\n%s", typ, recovered, stack)
+			if onPanic != nil {
+				onPanic(recovered, stack)
+			}
+		}
+	}()
+	fn(event)
+}
+
+// workerIndex returns the index of the worker, which must handle the event.
+func (d *Dispatcher) workerIndex(event string) int {
+	var header struct {
+		ChatID  int64 `json:"chat_id"`
+		Message struct {
+			ChatID int64 `json:"chat_id"`
+		} `json:"message"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	}
+	if err := json.Unmarshal(eventBytes(event), &header); err != nil {
+		return 0
+	}
+
+	chatID := header.ChatID
+	if chatID == 0 {
+		chatID = header.Message.ChatID
+	}
+	if chatID == 0 {
+		chatID = header.Chat.ID
+	}
+	if chatID == 0 {
+		return 0
+	}
+	return int(uint64(chatID) % uint64(len(d.workers)))
+}
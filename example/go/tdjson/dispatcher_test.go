@@ -0,0 +1,277 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func runDispatcher(t *testing.T, d *Dispatcher) (wait func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+	return func() {
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("Run returned %v", err)
+		}
+	}
+}
+
+func TestDispatcherAuthorizationStateOrder(t *testing.T) {
+	client, td := newFakeClient(t)
+	d := NewDispatcher(client, 4)
+
+	states := []string{
+		"authorizationStateWaitTdlibParameters",
+		"authorizationStateWaitEncryptionKey",
+		"authorizationStateWaitPhoneNumber",
+		"authorizationStateWaitCode",
+		"authorizationStateReady",
+	}
+	var mu sync.Mutex
+	var got []string
+	received := make(chan struct{})
+	d.OnUpdate("updateAuthorizationState", func(update json.RawMessage) {
+		var u struct {
+			State struct {
+				Type string `json:"@type"`
+			} `json:"authorization_state"`
+		}
+		if err := json.Unmarshal(update, &u); err != nil {
+			t.Error(err)
+		}
+		time.Sleep(time.Duration(rand.Intn(1000)) * time.Microsecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, u.State.Type)
+		if len(got) == len(states) {
+			close(received)
+		}
+	})
+	wait := runDispatcher(t, d)
+	defer wait()
+
+	for _, state := range states {
+		td.push(client.clientID, authorizationStateUpdate(state))
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for updates")
+	}
+	if !reflect.DeepEqual(got, states) {
+		t.Errorf("got states %v, want %v", got, states)
+	}
+}
+
+func TestDispatcherNewMessageOrderPerChat(t *testing.T) {
+	client, td := newFakeClient(t)
+	d := NewDispatcher(client, 4)
+
+	const chatCount = 8
+	const messageCount = 50
+	var mu sync.Mutex
+	got := make(map[int64][]int64)
+	var wg sync.WaitGroup
+	wg.Add(chatCount * messageCount)
+	d.OnUpdate("updateNewMessage", func(update json.RawMessage) {
+		defer wg.Done()
+		var u struct {
+			Message struct {
+				ID     int64 `json:"id"`
+				ChatID int64 `json:"chat_id"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(update, &u); err != nil {
+			t.Error(err)
+		}
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+
+		mu.Lock()
+		got[u.Message.ChatID] = append(got[u.Message.ChatID], u.Message.ID)
+		mu.Unlock()
+	})
+	unhandled := make(chan string, 10)
+	d.OnUnhandled(func(update json.RawMessage) {
+		unhandled <- string(update)
+	})
+	wait := runDispatcher(t, d)
+
+	for i := 1; i <= messageCount; i++ {
+		for chatID := int64(-chatCount); chatID < 0; chatID++ {
+			td.push(client.clientID, map[string]interface{}{
+				"@type":   "updateNewMessage",
+				"message": map[string]interface{}{"@type": "message", "id": i, "chat_id": chatID},
+			})
+		}
+	}
+	td.push(client.clientID, map[string]interface{}{"@type": "updateUnknown"})
+	wg.Wait()
+	select {
+	case <-unhandled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an unhandled update")
+	}
+	wait()
+
+	for chatID, ids := range got {
+		for i, id := range ids {
+			if id != int64(i+1) {
+				t.Fatalf("chat %d: got messages %v out of order", chatID, ids)
+			}
+		}
+	}
+	if len(got) != chatCount {
+		t.Errorf("got messages for %d chats, want %d", len(got), chatCount)
+	}
+	if len(unhandled) != 0 {
+		t.Errorf("got %d more unhandled updates", len(unhandled))
+	}
+}
+
+func TestDispatcherRecoversHandlerPanic(t *testing.T) {
+	client, td := newFakeClient(t)
+	d := NewDispatcher(client, 2)
+
+	received := make(chan int64, 10)
+	d.OnUpdate("updateNewMessage", func(update json.RawMessage) {
+		var u struct {
+			Message struct {
+				ID int64 `json:"id"`
+			} `json:"message"`
+		}
+		json.Unmarshal(update, &u)
+		if u.Message.ID == 2 {
+			panic("test panic")
+		}
+		received <- u.Message.ID
+	})
+	panics := make(chan interface{}, 10)
+	d.OnPanic(func(recovered interface{}, stack []byte) {
+		if len(stack) == 0 {
+			t.Error("stack trace is empty")
+		}
+		panics <- recovered
+	})
+	wait := runDispatcher(t, d)
+	defer wait()
+
+	for id := 1; id <= 3; id++ {
+		td.push(client.clientID, map[string]interface{}{
+			"@type":   "updateNewMessage",
+			"message": map[string]interface{}{"@type": "message", "id": id, "chat_id": 1},
+		})
+	}
+	for _, want := range []int64{1, 3} {
+		select {
+		case id := <-received:
+			if id != want {
+				t.Errorf("got message %d, want %d", id, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for updates")
+		}
+	}
+	if recovered := <-panics; recovered != "test panic" {
+		t.Errorf("got recovered value %v", recovered)
+	}
+}
+
+// largeEvent returns an updateNewMessage event with the text of the given size.
+func largeEvent(textSize int) string {
+	return `{"@type":"updateNewMessage","@client_id":1,"message":{"@type":"message","id":1048576,"chat_id":-1001,` +
+		`"content":{"@type":"messageText","text":{"@type":"formattedText","text":"` + strings.Repeat("x", textSize) +
+		`","entities":[]}}}}`
+}
+
+func TestDispatchDoesNotCopyEvents(t *testing.T) {
+	d := NewDispatcher(nil, 4)
+	d.OnUpdate("updateNewMessage", func(update json.RawMessage) {})
+	event := largeEvent(1 << 20)
+
+	// the event is decoded in place, so the allocated memory doesn't depend on its size
+	result := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, ok := parseEventHeader(event); !ok {
+				b.Fatal("failed to parse the event")
+			}
+			d.workerIndex(event)
+			d.handle(json.RawMessage(eventBytes(event)))
+		}
+	})
+	if bytes := result.AllocedBytesPerOp(); bytes > int64(len(event))/100 {
+		t.Errorf("%d bytes are allocated to route an event of %d bytes", bytes, len(event))
+	}
+}
+
+func BenchmarkDispatchLargeEvent(b *testing.B) {
+	d := NewDispatcher(nil, 4)
+	d.OnUpdate("updateNewMessage", func(update json.RawMessage) {})
+	event := largeEvent(64 << 10)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(event)))
+	for i := 0; i < b.N; i++ {
+		parseEventHeader(event)
+		d.workerIndex(event)
+		d.handle(json.RawMessage(eventBytes(event)))
+	}
+}
+
+func BenchmarkDispatcher(b *testing.B) {
+	td := newFakeBackend()
+	m := newManager(context.Background(), td)
+	defer m.Close()
+	client := m.NewClient()
+	defer client.Destroy()
+
+	d := NewDispatcher(client, 4)
+	var wg sync.WaitGroup
+	d.OnUpdate("updateNewMessage", func(update json.RawMessage) {
+		var u struct {
+			Message struct {
+				ID int64 `json:"id"`
+			} `json:"message"`
+		}
+		json.Unmarshal(update, &u)
+		wg.Done()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- d.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	events := make([]string, 64)
+	for i := range events {
+		events[i] = `{"@type":"updateNewMessage","@client_id":1,"message":{"@type":"message","id":` +
+			strconv.Itoa(i<<20) + `,"chat_id":-100` + strconv.Itoa(i) + `,"content":{"@type":"messageText",` +
+			`"text":{"@type":"formattedText","text":"` + strings.Repeat("text ", 50) + `","entities":[]}}}}`
+	}
+	wg.Add(b.N)
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		td.events <- events[i%len(events)]
+	}
+	wg.Wait()
+	b.ReportMetric(float64(b.N)/time.Since(start).Seconds(), "updates/s")
+}
@@ -0,0 +1,64 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Error is an error object returned by TDLib in response to a request,
+// for example {"@type":"error","code":400,"message":"CHAT_NOT_FOUND"}.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("tdjson: TDLib error %d: %s", e.Code, e.Message)
+}
+
+// responseError returns an *Error if the response is a TDLib error object, and nil otherwise.
+func responseError(response []byte) error {
+	var object struct {
+		Type string `json:"@type"`
+		Error
+	}
+	if err := json.Unmarshal(response, &object); err != nil {
+		return fmt.Errorf("tdjson: failed to parse response: %w", err)
+	}
+	if object.Type != "error" {
+		return nil
+	}
+	return &object.Error
+}
+
+// floodWaitRegexp matches "Too Many Requests: retry after N" returned by TDLib and "FLOOD_WAIT_N" returned by Telegram.
+var floodWaitRegexp = regexp.MustCompile(`(?i)(?:retry after|FLOOD_WAIT_)\s*(\d+)`)
+
+// IsFloodWait returns true and the time after which the request can be repeated,
+// if the error is a TDLib error caused by too many requests.
+func IsFloodWait(err error) (retryAfter time.Duration, ok bool) {
+	var tdErr *Error
+	if !errors.As(err, &tdErr) || (tdErr.Code != 429 && tdErr.Code != 420) {
+		return 0, false
+	}
+	match := floodWaitRegexp.FindStringSubmatch(tdErr.Message)
+	if match == nil {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
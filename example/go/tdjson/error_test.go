@@ -0,0 +1,40 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestIsFloodWait(t *testing.T) {
+	tests := []struct {
+		err        error
+		retryAfter time.Duration
+		ok         bool
+	}{
+		{&Error{429, "Too Many Requests: retry after 35"}, 35 * time.Second, true},
+		{&Error{429, "Too Many Requests: retry after 1"}, time.Second, true},
+		{&Error{429, "too many requests: Retry After 7"}, 7 * time.Second, true},
+		{&Error{420, "FLOOD_WAIT_120"}, 2 * time.Minute, true},
+		{fmt.Errorf("sending message: %w", &Error{429, "Too Many Requests: retry after 3"}), 3 * time.Second, true},
+		{&Error{429, "Too Many Requests"}, 0, false},
+		{&Error{400, "CHAT_NOT_FOUND"}, 0, false},
+		{&Error{400, "retry after 5"}, 0, false},
+		{errors.New("Too Many Requests: retry after 5"), 0, false},
+		{nil, 0, false},
+	}
+	for _, test := range tests {
+		retryAfter, ok := IsFloodWait(test.err)
+		if retryAfter != test.retryAfter || ok != test.ok {
+			t.Errorf("IsFloodWait(%v) = %v, %v, want %v, %v", test.err, retryAfter, ok, test.retryAfter, test.ok)
+		}
+	}
+}
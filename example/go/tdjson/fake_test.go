@@ -0,0 +1,135 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend is a backend, which returns events pushed by a test and passes sent requests to a handler.
+type fakeBackend struct {
+	mu           sync.Mutex
+	lastClientID int
+	onSend       func(clientID int, request map[string]interface{})
+	timeouts     []float64 // timeouts passed to receive
+	timedOut     string    // returned by receive along with false if there are no events
+
+	events chan string
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{events: make(chan string, 1000)}
+}
+
+func (b *fakeBackend) createClientID() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastClientID++
+	return b.lastClientID
+}
+
+func (b *fakeBackend) send(clientID int, request string) {
+	b.mu.Lock()
+	onSend := b.onSend
+	b.mu.Unlock()
+	if onSend == nil {
+		return
+	}
+
+	var query map[string]interface{}
+	if err := json.Unmarshal([]byte(request), &query); err != nil {
+		panic(err)
+	}
+	onSend(clientID, query)
+}
+
+func (b *fakeBackend) sendBatch(clientID int, requests []string) {
+	for _, request := range requests {
+		b.send(clientID, request)
+	}
+}
+
+func (b *fakeBackend) receive(timeout float64) (string, bool) {
+	b.mu.Lock()
+	b.timeouts = append(b.timeouts, timeout)
+	timedOut := b.timedOut
+	b.mu.Unlock()
+
+	select {
+	case event := <-b.events:
+		return event, true
+	case <-time.After(10 * time.Millisecond):
+		return timedOut, false
+	}
+}
+
+// handleSend sets the handler for requests sent to the backend.
+func (b *fakeBackend) handleSend(onSend func(clientID int, request map[string]interface{})) {
+	b.mu.Lock()
+	b.onSend = onSend
+	b.mu.Unlock()
+}
+
+// respond sets the handler for requests sent to the backend to push the object returned by answer as the result of
+// the request. Requests, for which answer returns nil, are left unanswered.
+func (b *fakeBackend) respond(answer func(request map[string]interface{}) map[string]interface{}) {
+	b.handleSend(func(clientID int, request map[string]interface{}) {
+		if object := answer(request); object != nil {
+			b.push(clientID, withExtra(object, request))
+		}
+	})
+}
+
+// withExtra sets "@extra" of the object to the one of the request, so the object becomes the result of the request.
+func withExtra(object map[string]interface{}, request map[string]interface{}) map[string]interface{} {
+	object["@extra"] = request["@extra"]
+	return object
+}
+
+// receiveTimeouts returns the timeouts passed to receive so far.
+func (b *fakeBackend) receiveTimeouts() []float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]float64(nil), b.timeouts...)
+}
+
+// push makes the event receivable by the client with the given identifier.
+func (b *fakeBackend) push(clientID int, event map[string]interface{}) {
+	object := map[string]interface{}{"@client_id": clientID}
+	for key, value := range event {
+		object[key] = value
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		panic(err)
+	}
+	b.events <- string(data)
+}
+
+// newFakeClient returns a client of a Manager with a fake backend.
+func newFakeClient(t *testing.T) (*Client, *fakeBackend) {
+	b := newFakeBackend()
+	m := newManager(context.Background(), b)
+	c := m.NewClient()
+	t.Cleanup(func() {
+		c.Destroy()
+		m.Close()
+	})
+	return c, b
+}
+
+func authorizationStateUpdate(state string) map[string]interface{} {
+	return map[string]interface{}{
+		"@type":               "updateAuthorizationState",
+		"authorization_state": map[string]interface{}{"@type": state},
+	}
+}
@@ -0,0 +1,385 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrDownloadStopped is returned if a file download stops before the file is completely downloaded.
+var ErrDownloadStopped = errors.New("tdjson: file download was stopped")
+
+// ErrUploadStopped is returned if a file upload stops before the file is completely uploaded.
+var ErrUploadStopped = errors.New("tdjson: file upload was stopped")
+
+// File represents a file object.
+type File struct {
+	ID           int32      `json:"id"`
+	Size         int32      `json:"size"`
+	ExpectedSize int32      `json:"expected_size"`
+	Local        LocalFile  `json:"local"`
+	Remote       RemoteFile `json:"remote"`
+}
+
+// LocalFile represents a localFile object.
+type LocalFile struct {
+	Path                   string `json:"path"`
+	CanBeDownloaded        bool   `json:"can_be_downloaded"`
+	CanBeDeleted           bool   `json:"can_be_deleted"`
+	IsDownloadingActive    bool   `json:"is_downloading_active"`
+	IsDownloadingCompleted bool   `json:"is_downloading_completed"`
+	DownloadOffset         int32  `json:"download_offset"`
+	DownloadedPrefixSize   int32  `json:"downloaded_prefix_size"`
+	DownloadedSize         int32  `json:"downloaded_size"`
+}
+
+// RemoteFile represents a remoteFile object.
+type RemoteFile struct {
+	ID                   string `json:"id"`
+	UniqueID             string `json:"unique_id"`
+	IsUploadingActive    bool   `json:"is_uploading_active"`
+	IsUploadingCompleted bool   `json:"is_uploading_completed"`
+	UploadedSize         int32  `json:"uploaded_size"`
+}
+
+// FileProgress describes progress of a file download or upload.
+type FileProgress struct {
+	File File
+
+	// DownloadedSize is the number of downloaded bytes.
+	DownloadedSize int64
+
+	// UploadedSize is the number of uploaded bytes.
+	UploadedSize int64
+
+	// ExpectedSize is the expected size of the file in bytes. It is 0 if the size is unknown.
+	ExpectedSize int64
+
+	// Err is non-nil in the last progress sent before a failed download or upload stops.
+	Err error
+}
+
+func newFileProgress(file File) FileProgress {
+	expectedSize := file.Size
+	if expectedSize == 0 {
+		expectedSize = file.ExpectedSize
+	}
+	return FileProgress{
+		File:           file,
+		DownloadedSize: int64(file.Local.DownloadedSize),
+		UploadedSize:   int64(file.Remote.UploadedSize),
+		ExpectedSize:   int64(expectedSize),
+	}
+}
+
+// fileTransfer describes how to track a file download or upload.
+type fileTransfer struct {
+	state   func(file *File) (isActive bool, isCompleted bool)
+	cancel  map[string]interface{} // the request canceling the transfer
+	stopped error                  // returned if the transfer stops before completion
+}
+
+var fileDownload = fileTransfer{
+	state: func(file *File) (bool, bool) {
+		return file.Local.IsDownloadingActive, file.Local.IsDownloadingCompleted
+	},
+	stopped: ErrDownloadStopped,
+}
+
+var fileUpload = fileTransfer{
+	state: func(file *File) (bool, bool) {
+		return file.Remote.IsUploadingActive, file.Remote.IsUploadingCompleted
+	},
+	stopped: ErrUploadStopped,
+}
+
+// fileEvents is a source of updateFile events for a transfer of a file.
+type fileEvents interface {
+	next(ctx context.Context) (string, error)
+
+	// close stops receiving of the events. It returns true if nobody else tracks the transfer,
+	// so it must be canceled if it isn't finished.
+	close() bool
+}
+
+// fileSubscriptions routes updateFile events to subscriptions by the identifier of the file.
+// TDLib sends a single stream of updates for a file, so all downloads of the same file share it.
+type fileSubscriptions struct {
+	mu   sync.Mutex
+	subs map[int32]map[*fileSubscription]struct{}
+}
+
+// fileSubscription receives copies of updateFile events for a file.
+type fileSubscription struct {
+	client    *Client
+	fileID    int32
+	events    *eventQueue
+	closeOnce sync.Once
+}
+
+// subscribeFile starts copying of updateFile events for the file to the returned subscription.
+func (c *Client) subscribeFile(fileID int32) *fileSubscription {
+	s := &fileSubscription{client: c, fileID: fileID, events: newEventQueue()}
+
+	c.files.mu.Lock()
+	if c.files.subs == nil {
+		c.files.subs = make(map[int32]map[*fileSubscription]struct{})
+	}
+	if c.files.subs[fileID] == nil {
+		c.files.subs[fileID] = make(map[*fileSubscription]struct{})
+	}
+	c.files.subs[fileID][s] = struct{}{}
+	c.files.mu.Unlock()
+	return s
+}
+
+func (s *fileSubscriptions) publish(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.subs) == 0 {
+		return
+	}
+	var update struct {
+		File struct {
+			ID int32 `json:"id"`
+		} `json:"file"`
+	}
+	if err := json.Unmarshal([]byte(event), &update); err != nil {
+		return
+	}
+	for sub := range s.subs[update.File.ID] {
+		sub.events.push(event)
+	}
+}
+
+// next waits for the next event or for cancellation of the context.
+func (s *fileSubscription) next(ctx context.Context) (string, error) {
+	return s.client.nextEvent(ctx, s.events)
+}
+
+// close stops copying of events to the subscription. Only the first call can return true.
+func (s *fileSubscription) close() (last bool) {
+	s.closeOnce.Do(func() {
+		files := &s.client.files
+		files.mu.Lock()
+		delete(files.subs[s.fileID], s)
+		if len(files.subs[s.fileID]) == 0 {
+			delete(files.subs, s.fileID)
+			last = true
+		}
+		files.mu.Unlock()
+	})
+	return last
+}
+
+// uploadEvents receives all updateFile events, because the identifier of an uploaded file isn't known
+// before uploadFile returns. Each upload is tracked only by its own subscription.
+type uploadEvents struct {
+	*subscription
+}
+
+func (s uploadEvents) close() bool {
+	s.subscription.close()
+	return true
+}
+
+// DownloadFile starts asynchronous download of the file and returns a channel receiving progress of the download.
+// The channel is closed after the file is downloaded or the download fails, in which case the last
+// progress has a non-nil Err, for example ErrClientDestroyed if the client is destroyed before the download
+// is finished. Cancellation of the context cancels the download, unless the same file
+// is also being downloaded by another call of DownloadFile.
+func (c *Client) DownloadFile(ctx context.Context, fileID int32, priority int32) (<-chan FileProgress, error) {
+	sub := c.subscribeFile(fileID)
+	result, err := c.Call(ctx, map[string]interface{}{
+		"@type":       "downloadFile",
+		"file_id":     fileID,
+		"priority":    priority,
+		"offset":      0,
+		"limit":       0,
+		"synchronous": false,
+	})
+	if err != nil {
+		sub.close()
+		return nil, err
+	}
+	var file File
+	if err := json.Unmarshal(result, &file); err != nil {
+		sub.close()
+		return nil, err
+	}
+
+	transfer := fileDownload
+	transfer.cancel = map[string]interface{}{"@type": "cancelDownloadFile", "file_id": fileID, "only_if_pending": false}
+	return c.trackFile(ctx, sub, file, transfer), nil
+}
+
+// UploadFile starts asynchronous upload of the local file with the given path to the cloud and returns
+// the identifier of the file and a channel receiving progress of the upload. The fileType is a type of the file,
+// for example "fileTypeDocument" or "fileTypePhoto". The priority must be from 1 to 32; the higher the priority,
+// the earlier the file is uploaded.
+//
+// The channel is closed after the file is uploaded or the upload fails, in which case the last progress
+// has a non-nil Err. Cancellation of the context cancels the upload.
+func (c *Client) UploadFile(ctx context.Context, path string, fileType string, priority int32) (<-chan FileProgress, int32, error) {
+	sub := c.subscribeTypes("updateFile")
+	result, err := c.Call(ctx, map[string]interface{}{
+		"@type":     "uploadFile",
+		"file":      map[string]interface{}{"@type": "inputFileLocal", "path": path},
+		"file_type": map[string]interface{}{"@type": fileType},
+		"priority":  priority,
+	})
+	if err != nil {
+		sub.close()
+		return nil, 0, err
+	}
+	var file File
+	if err := json.Unmarshal(result, &file); err != nil {
+		sub.close()
+		return nil, 0, err
+	}
+
+	transfer := fileUpload
+	transfer.cancel = map[string]interface{}{"@type": "cancelUploadFile", "file_id": file.ID}
+	return c.trackFile(ctx, uploadEvents{sub}, file, transfer), file.ID, nil
+}
+
+// trackFile sends progress of the transfer of the file, till it is completed or stopped, to the returned channel.
+// The transfer also stops with ErrClientDestroyed or ErrClientClosed when the client stops.
+// The subscription to updateFile is closed afterwards.
+func (c *Client) trackFile(ctx context.Context, sub fileEvents, file File, transfer fileTransfer) <-chan FileProgress {
+	progress := make(chan FileProgress, 1)
+	go func() {
+		defer close(progress)
+
+		stop := func(err error) {
+			if sub.close() {
+				c.send(transfer.cancel)
+			}
+			// the receiver may be gone, so an unread progress is replaced with the final one instead of blocking
+			select {
+			case <-progress:
+			default:
+			}
+			progress <- FileProgress{File: file, Err: err}
+		}
+		for {
+			update := newFileProgress(file)
+			isActive, isCompleted := transfer.state(&file)
+			if !isCompleted && !isActive {
+				update.Err = transfer.stopped
+			}
+			select {
+			case progress <- update:
+			case <-ctx.Done():
+				stop(ctx.Err())
+				return
+			case <-c.calls.abortedChan():
+				stop(c.calls.abortError())
+				return
+			}
+			if isCompleted || update.Err != nil {
+				sub.close()
+				return
+			}
+
+			for {
+				event, err := sub.next(ctx)
+				if err != nil {
+					stop(err)
+					return
+				}
+				var u struct {
+					File File `json:"file"`
+				}
+				if err := json.Unmarshal([]byte(event), &u); err == nil && u.File.ID == file.ID {
+					file = u.File
+					break
+				}
+			}
+		}
+	}()
+	return progress
+}
+
+// downloadPriority is the priority of downloads by DownloadToBytes and DownloadToPath, which are waited for.
+const downloadPriority = 32
+
+// downloadLocalFile downloads the file and opens the downloaded local file for reading.
+func (c *Client) downloadLocalFile(ctx context.Context, fileID int32) (*os.File, error) {
+	progress, err := c.DownloadFile(ctx, fileID, downloadPriority)
+	if err != nil {
+		return nil, err
+	}
+	var last FileProgress
+	for p := range progress {
+		last = p
+	}
+	if last.Err != nil {
+		return nil, last.Err
+	}
+	if !last.File.Local.IsDownloadingCompleted {
+		return nil, ErrDownloadStopped
+	}
+
+	f, err := os.Open(last.File.Local.Path)
+	if err != nil {
+		return nil, err
+	}
+	// TDLib may delete files from its cache at any time, so the file can be incomplete or missing
+	if info, err := f.Stat(); err != nil || (last.File.Size != 0 && info.Size() != int64(last.File.Size)) {
+		f.Close()
+		if err == nil {
+			err = fmt.Errorf("tdjson: downloaded file %s has %d bytes instead of %d", last.File.Local.Path, info.Size(), last.File.Size)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// DownloadToBytes downloads the file and returns its content. ErrDownloadStopped is returned
+// if the download stops before the file is completely downloaded.
+func (c *Client) DownloadToBytes(ctx context.Context, fileID int32) ([]byte, error) {
+	f, err := c.downloadLocalFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// DownloadToPath downloads the file and copies it to dest, which is overwritten if it exists.
+// The file is copied rather than moved, because TDLib manages the files in its files directory itself.
+// ErrDownloadStopped is returned if the download stops before the file is completely downloaded.
+func (c *Client) DownloadToPath(ctx context.Context, fileID int32, dest string) error {
+	f, err := c.downloadLocalFile(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, f); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dest)
+		return err
+	}
+	return nil
+}
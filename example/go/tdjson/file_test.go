@@ -0,0 +1,514 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func fileObject(id int32, downloadedSize int32, isActive bool, isCompleted bool) map[string]interface{} {
+	return map[string]interface{}{
+		"@type":         "file",
+		"id":            id,
+		"size":          1000,
+		"expected_size": 1000,
+		"local": map[string]interface{}{
+			"@type":                    "localFile",
+			"path":                     "/tmp/file",
+			"is_downloading_active":    isActive,
+			"is_downloading_completed": isCompleted,
+			"downloaded_size":          downloadedSize,
+		},
+		"remote": map[string]interface{}{"@type": "remoteFile"},
+	}
+}
+
+func fileUpdate(file map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"@type": "updateFile", "file": file}
+}
+
+func TestDownloadFile(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "downloadFile" {
+			td.push(clientID, withExtra(fileObject(7, 0, true, false), request))
+			td.push(clientID, fileUpdate(fileObject(8, 100, true, false)))
+			td.push(clientID, fileUpdate(fileObject(7, 500, true, false)))
+			td.push(clientID, fileUpdate(fileObject(7, 1000, false, true)))
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	progress, err := client.DownloadFile(ctx, 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int64
+	for p := range progress {
+		if p.Err != nil {
+			t.Fatal(p.Err)
+		}
+		if p.ExpectedSize != 1000 {
+			t.Errorf("got expected size %d", p.ExpectedSize)
+		}
+		sizes = append(sizes, p.DownloadedSize)
+	}
+	if len(sizes) != 3 || sizes[0] != 0 || sizes[1] != 500 || sizes[2] != 1000 {
+		t.Errorf("got progress %v, want [0 500 1000]", sizes)
+	}
+}
+
+func TestDownloadFileStopped(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "downloadFile" {
+			td.push(clientID, withExtra(fileObject(7, 0, true, false), request))
+			td.push(clientID, fileUpdate(fileObject(7, 100, false, false)))
+		}
+	})
+
+	progress, err := client.DownloadFile(context.Background(), 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last FileProgress
+	for p := range progress {
+		last = p
+	}
+	if last.Err != ErrDownloadStopped {
+		t.Errorf("got error %v, want %v", last.Err, ErrDownloadStopped)
+	}
+}
+
+func TestDownloadFileCancel(t *testing.T) {
+	client, td := newFakeClient(t)
+	canceled := make(chan interface{}, 1)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "downloadFile":
+			td.push(clientID, withExtra(fileObject(7, 0, true, false), request))
+		case "cancelDownloadFile":
+			canceled <- request["file_id"]
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	progress, err := client.DownloadFile(ctx, 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-progress
+	cancel()
+	for p := range progress {
+		if p.Err != context.Canceled {
+			t.Errorf("got error %v, want %v", p.Err, context.Canceled)
+		}
+	}
+	select {
+	case fileID := <-canceled:
+		if fileID != 7.0 {
+			t.Errorf("got canceled file %v, want 7", fileID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("download wasn't canceled")
+	}
+}
+
+func TestDownloadFileConcurrent(t *testing.T) {
+	client, td := newFakeClient(t)
+	var mu sync.Mutex
+	var downloads int
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "downloadFile" {
+			return
+		}
+		td.push(clientID, withExtra(fileObject(7, 0, true, false), request))
+		mu.Lock()
+		downloads++
+		last := downloads == 2
+		mu.Unlock()
+		// TDLib sends a single stream of updates for the file after the second download joins the first
+		if last {
+			td.push(clientID, fileUpdate(fileObject(7, 500, true, false)))
+			td.push(clientID, fileUpdate(fileObject(7, 1000, false, true)))
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	results := make([][]FileProgress, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			progress, err := client.DownloadFile(ctx, 7, 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for p := range progress {
+				results[i] = append(results[i], p)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if len(result) == 0 {
+			t.Fatalf("download %d received no progress", i)
+		}
+		last := result[len(result)-1]
+		if last.Err != nil || !last.File.Local.IsDownloadingCompleted || last.DownloadedSize != 1000 {
+			t.Errorf("download %d finished with progress %+v", i, last)
+		}
+	}
+	client.files.mu.Lock()
+	defer client.files.mu.Unlock()
+	if len(client.files.subs) != 0 {
+		t.Errorf("got %d files with subscriptions after downloads finished", len(client.files.subs))
+	}
+}
+
+func TestDownloadFileConcurrentCancel(t *testing.T) {
+	client, td := newFakeClient(t)
+	canceled := make(chan interface{}, 2)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "downloadFile":
+			td.push(clientID, withExtra(fileObject(7, 0, true, false), request))
+		case "cancelDownloadFile":
+			canceled <- request["file_id"]
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	firstCtx, cancelFirst := context.WithCancel(ctx)
+
+	first, err := client.DownloadFile(firstCtx, 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.DownloadFile(ctx, 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-first
+	<-second
+
+	// the second download must continue after the first one is canceled
+	cancelFirst()
+	for p := range first {
+		if p.Err != context.Canceled {
+			t.Errorf("got error %v, want %v", p.Err, context.Canceled)
+		}
+	}
+	td.push(client.clientID, fileUpdate(fileObject(7, 1000, false, true)))
+	var last FileProgress
+	for p := range second {
+		last = p
+	}
+	if last.Err != nil || !last.File.Local.IsDownloadingCompleted {
+		t.Errorf("got last progress %+v", last)
+	}
+	select {
+	case fileID := <-canceled:
+		t.Errorf("download of file %v was canceled", fileID)
+	default:
+	}
+}
+
+// scriptDownload makes the backend complete downloads of a file with the given local path and size immediately.
+func scriptDownload(t *testing.T, td *fakeBackend, path string, size int) {
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "downloadFile" {
+			return
+		}
+		if request["priority"] != float64(downloadPriority) {
+			t.Errorf("got priority %v", request["priority"])
+		}
+		file := fileObject(7, int32(size), false, true)
+		file["size"] = size
+		file["local"].(map[string]interface{})["path"] = path
+		td.push(clientID, withExtra(file, request))
+	})
+}
+
+func TestDownloadToBytes(t *testing.T) {
+	client, td := newFakeClient(t)
+	content := []byte("downloaded content")
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	scriptDownload(t, td, path, len(content))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data, err := client.DownloadToBytes(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, content) {
+		t.Errorf("got content %q", data)
+	}
+}
+
+func TestDownloadToBytesIncomplete(t *testing.T) {
+	client, td := newFakeClient(t)
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scriptDownload(t, td, path, 1000)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.DownloadToBytes(ctx, 7); err == nil {
+		t.Error("incomplete file was returned")
+	}
+}
+
+func TestDownloadToBytesStopped(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "downloadFile" {
+			td.push(clientID, withExtra(fileObject(7, 0, true, false), request))
+			td.push(clientID, fileUpdate(fileObject(7, 100, false, false)))
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.DownloadToBytes(ctx, 7); err != ErrDownloadStopped {
+		t.Errorf("got error %v, want %v", err, ErrDownloadStopped)
+	}
+}
+
+func TestDownloadToBytesDestroyed(t *testing.T) {
+	client, td := newFakeClient(t)
+	sent := make(chan struct{})
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "downloadFile" {
+			td.push(clientID, withExtra(fileObject(7, 0, true, false), request))
+			close(sent)
+		}
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.DownloadToBytes(context.Background(), 7)
+		done <- err
+	}()
+	<-sent
+	client.Destroy()
+	waitError(t, done, ErrClientDestroyed)
+}
+
+func TestDownloadFileDestroyed(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "downloadFile" {
+			td.push(clientID, withExtra(fileObject(7, 0, true, false), request))
+		}
+	})
+	progress, err := client.DownloadFile(context.Background(), 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first progress isn't read before the client is destroyed, but the final one must be received anyway
+	client.Destroy()
+	var last FileProgress
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case p, ok := <-progress:
+			if ok {
+				last = p
+				continue
+			}
+		case <-timeout:
+			t.Fatal("progress channel wasn't closed")
+		}
+		break
+	}
+	if last.Err != ErrClientDestroyed {
+		t.Errorf("got last progress %+v, want error %v", last, ErrClientDestroyed)
+	}
+}
+
+func TestDownloadToPath(t *testing.T) {
+	client, td := newFakeClient(t)
+	dir := t.TempDir()
+	content := []byte("downloaded content")
+	path := filepath.Join(dir, "cached")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	scriptDownload(t, td, path, len(content))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dest := filepath.Join(dir, "dest")
+	if err := client.DownloadToPath(ctx, 7, dest); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(dest); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("got content %q, %v", data, err)
+	}
+	// the file in the TDLib cache must remain in place
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+
+	if err := client.DownloadToPath(ctx, 7, filepath.Join(dir, "missing", "dest")); err == nil {
+		t.Error("copying to a missing directory succeeded")
+	}
+}
+
+func uploadedFileObject(id int32, uploadedSize int32, isActive bool, isCompleted bool) map[string]interface{} {
+	file := fileObject(id, 1000, false, true)
+	file["remote"] = map[string]interface{}{
+		"@type":                  "remoteFile",
+		"is_uploading_active":    isActive,
+		"is_uploading_completed": isCompleted,
+		"uploaded_size":          uploadedSize,
+	}
+	return file
+}
+
+func TestUploadFile(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "uploadFile" {
+			return
+		}
+		file := request["file"].(map[string]interface{})
+		if file["@type"] != "inputFileLocal" || file["path"] != "/tmp/file" {
+			t.Errorf("got file %v", file)
+		}
+		if fileType := request["file_type"].(map[string]interface{}); fileType["@type"] != "fileTypeDocument" {
+			t.Errorf("got file type %v", fileType)
+		}
+		if request["priority"] != 16.0 {
+			t.Errorf("got priority %v", request["priority"])
+		}
+		td.push(clientID, withExtra(uploadedFileObject(9, 0, true, false), request))
+		td.push(clientID, fileUpdate(uploadedFileObject(8, 100, true, false)))
+		td.push(clientID, fileUpdate(uploadedFileObject(9, 400, true, false)))
+		td.push(clientID, fileUpdate(uploadedFileObject(9, 1000, false, true)))
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	progress, fileID, err := client.UploadFile(ctx, "/tmp/file", "fileTypeDocument", 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileID != 9 {
+		t.Errorf("got file identifier %d", fileID)
+	}
+	var sizes []int64
+	for p := range progress {
+		if p.Err != nil {
+			t.Fatal(p.Err)
+		}
+		sizes = append(sizes, p.UploadedSize)
+	}
+	if len(sizes) != 3 || sizes[0] != 0 || sizes[1] != 400 || sizes[2] != 1000 {
+		t.Errorf("got progress %v, want [0 400 1000]", sizes)
+	}
+}
+
+func TestUploadFileAlreadyUploaded(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "uploadFile" {
+			td.push(clientID, withExtra(uploadedFileObject(9, 1000, false, true), request))
+		}
+	})
+
+	progress, _, err := client.UploadFile(context.Background(), "/tmp/file", "fileTypePhoto", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	for p := range progress {
+		if p.Err != nil || !p.File.Remote.IsUploadingCompleted {
+			t.Errorf("got progress %+v", p)
+		}
+		count++
+	}
+	if count != 1 {
+		t.Errorf("got %d progress updates", count)
+	}
+}
+
+func TestUploadFileStopped(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "uploadFile" {
+			td.push(clientID, withExtra(uploadedFileObject(9, 0, true, false), request))
+			td.push(clientID, fileUpdate(uploadedFileObject(9, 100, false, false)))
+		}
+	})
+
+	progress, _, err := client.UploadFile(context.Background(), "/tmp/file", "fileTypeDocument", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last FileProgress
+	for p := range progress {
+		last = p
+	}
+	if last.Err != ErrUploadStopped {
+		t.Errorf("got error %v, want %v", last.Err, ErrUploadStopped)
+	}
+}
+
+func TestUploadFileCancel(t *testing.T) {
+	client, td := newFakeClient(t)
+	canceled := make(chan map[string]interface{}, 1)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "uploadFile":
+			td.push(clientID, withExtra(uploadedFileObject(9, 0, true, false), request))
+		case "cancelUploadFile", "cancelDownloadFile":
+			canceled <- request
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	progress, _, err := client.UploadFile(ctx, "/tmp/file", "fileTypeDocument", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-progress
+	cancel()
+	for p := range progress {
+		if p.Err != context.Canceled {
+			t.Errorf("got error %v, want %v", p.Err, context.Canceled)
+		}
+	}
+	select {
+	case request := <-canceled:
+		if request["@type"] != "cancelUploadFile" || request["file_id"] != 9.0 {
+			t.Errorf("got cancel request %v", request)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("upload wasn't canceled")
+	}
+}
@@ -0,0 +1,112 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// maxHistoryLimit is the maximum number of messages, which can be requested by one getChatHistory request.
+const maxHistoryLimit = 100
+
+// IterOptions contains options of a MessageIterator.
+type IterOptions struct {
+	// FromMessageID is the identifier of the newest returned message; use 0 to start from the last message.
+	FromMessageID int64
+
+	// PageSize is the number of messages requested by one getChatHistory request; up to 100, 100 if 0.
+	PageSize int32
+
+	// OnlyLocal restricts the iterator to messages available locally.
+	OnlyLocal bool
+}
+
+// MessageIterator iterates over messages of a chat in the order of decreasing message identifier.
+type MessageIterator struct {
+	client *Client
+	chatID int64
+	opts   IterOptions
+
+	page          []json.RawMessage
+	lastMessageID int64 // identifier of the last returned message, or 0 before the first message
+	requestCount  int
+	done          bool
+}
+
+// MessagesIterator returns an iterator over history of the chat, which requests the messages by pages.
+func (c *Client) MessagesIterator(chatID int64, opts IterOptions) *MessageIterator {
+	if opts.PageSize <= 0 || opts.PageSize > maxHistoryLimit {
+		opts.PageSize = maxHistoryLimit
+	}
+	return &MessageIterator{client: c, chatID: chatID, opts: opts}
+}
+
+// Next returns the next message. The second returned value is false if there are no more messages.
+func (it *MessageIterator) Next(ctx context.Context) (json.RawMessage, bool, error) {
+	for len(it.page) == 0 {
+		if it.done {
+			return nil, false, nil
+		}
+		if err := it.fetch(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+
+	message := it.page[0]
+	it.page = it.page[1:]
+	return message, true, nil
+}
+
+// fetch requests the next page of messages.
+func (it *MessageIterator) fetch(ctx context.Context) error {
+	fromMessageID := it.lastMessageID
+	if fromMessageID == 0 {
+		fromMessageID = it.opts.FromMessageID
+	}
+	result, err := it.client.Call(ctx, map[string]interface{}{
+		"@type":           "getChatHistory",
+		"chat_id":         it.chatID,
+		"from_message_id": fromMessageID,
+		"offset":          0,
+		"limit":           it.opts.PageSize,
+		"only_local":      it.opts.OnlyLocal,
+	})
+	if err != nil {
+		return err
+	}
+	it.requestCount++
+
+	var messages struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(result, &messages); err != nil {
+		return err
+	}
+	for _, message := range messages.Messages {
+		var header struct {
+			ID int64 `json:"id"`
+		}
+		if err := json.Unmarshal(message, &header); err != nil {
+			return err
+		}
+		// the message with identifier from_message_id is returned too
+		if header.ID == 0 || (it.lastMessageID != 0 && header.ID >= it.lastMessageID) {
+			continue
+		}
+		it.page = append(it.page, message)
+		it.lastMessageID = header.ID
+	}
+
+	// the first request after opening of a chat often returns fewer messages than requested, and even no messages
+	// if the chat history isn't loaded yet, so an empty first page is requested once more before giving up
+	if len(it.page) == 0 && it.requestCount > 1 {
+		it.done = true
+	}
+	return nil
+}
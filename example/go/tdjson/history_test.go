@@ -0,0 +1,95 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// historyBackend emulates getChatHistory for a chat with messages 1..messageCount. Like TDLib, it includes
+// from_message_id in results, and returns only one message in response to the first request.
+func historyBackend(t *testing.T, messageCount int64) (*Client, *int) {
+	client, td := newFakeClient(t)
+	requestCount := 0
+	td.respond(func(request map[string]interface{}) map[string]interface{} {
+		if request["@type"] != "getChatHistory" {
+			return nil
+		}
+		requestCount++
+		fromMessageID := int64(request["from_message_id"].(float64))
+		if fromMessageID == 0 {
+			fromMessageID = messageCount
+		}
+		limit := int64(request["limit"].(float64))
+		if requestCount == 1 {
+			limit = 1
+		}
+
+		messages := []interface{}{}
+		for id := fromMessageID; id > 0 && int64(len(messages)) < limit; id-- {
+			messages = append(messages, map[string]interface{}{"@type": "message", "id": id, "chat_id": request["chat_id"]})
+		}
+		return map[string]interface{}{"@type": "messages", "total_count": len(messages), "messages": messages}
+	})
+	return client, &requestCount
+}
+
+func TestMessagesIterator(t *testing.T) {
+	client, requestCount := historyBackend(t, 10)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	it := client.MessagesIterator(5, IterOptions{PageSize: 3})
+	wantID := int64(10)
+	for {
+		message, ok, err := it.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		var m struct {
+			ID     int64 `json:"id"`
+			ChatID int64 `json:"chat_id"`
+		}
+		if err := json.Unmarshal(message, &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.ID != wantID || m.ChatID != 5 {
+			t.Fatalf("got message %d in chat %d, want message %d in chat 5", m.ID, m.ChatID, wantID)
+		}
+		wantID--
+	}
+	if wantID != 0 {
+		t.Errorf("iteration stopped before message %d", wantID)
+	}
+	// 1 message, then 4 pages of 2 new messages, 1 page of 1 new message and a page without new messages
+	if *requestCount != 7 {
+		t.Errorf("sent %d requests, want 7", *requestCount)
+	}
+	if _, ok, _ := it.Next(ctx); ok {
+		t.Error("got a message after the end of the history")
+	}
+}
+
+func TestMessagesIteratorEmptyChat(t *testing.T) {
+	client, requestCount := historyBackend(t, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok, err := client.MessagesIterator(5, IterOptions{}).Next(ctx); ok || err != nil {
+		t.Errorf("got %v, %v, want no messages", ok, err)
+	}
+	if *requestCount != 2 {
+		t.Errorf("sent %d requests, want 2", *requestCount)
+	}
+}
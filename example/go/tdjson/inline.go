@@ -0,0 +1,193 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// InputMessageText returns the inputMessageText content of a message with the text.
+func InputMessageText(text FormattedText, disableWebPagePreview bool) map[string]interface{} {
+	return map[string]interface{}{
+		"@type":                    "inputMessageText",
+		"text":                     text,
+		"disable_web_page_preview": disableWebPagePreview,
+		"clear_draft":              false,
+	}
+}
+
+// InlineQueryResult is one of InlineArticle, InlinePhoto, InlineDocument and InlineContact,
+// which represent inputInlineQueryResult* objects.
+type InlineQueryResult interface {
+	json.Marshaler
+	isInlineQueryResult()
+}
+
+// InlineArticle is an inputInlineQueryResultArticle, a link to an article or web page.
+// Content must be specified, it is sent when the result is chosen.
+type InlineArticle struct {
+	ID              string                 `json:"id"`
+	URL             string                 `json:"url"`
+	HideURL         bool                   `json:"hide_url"`
+	Title           string                 `json:"title"`
+	Description     string                 `json:"description"`
+	ThumbnailURL    string                 `json:"thumbnail_url"`
+	ThumbnailWidth  int32                  `json:"thumbnail_width"`
+	ThumbnailHeight int32                  `json:"thumbnail_height"`
+	ReplyMarkup     interface{}            `json:"reply_markup"`
+	Content         map[string]interface{} `json:"input_message_content"`
+}
+
+// NewInlineArticle returns an article result, which sends the message with the content when chosen.
+func NewInlineArticle(id string, title string, content map[string]interface{}) *InlineArticle {
+	return &InlineArticle{ID: id, Title: title, Content: content}
+}
+
+// InlinePhoto is an inputInlineQueryResultPhoto, a link to a JPEG image.
+// If Content is nil, the photo itself is sent when the result is chosen.
+type InlinePhoto struct {
+	ID           string                 `json:"id"`
+	Title        string                 `json:"title"`
+	Description  string                 `json:"description"`
+	ThumbnailURL string                 `json:"thumbnail_url"`
+	PhotoURL     string                 `json:"photo_url"`
+	PhotoWidth   int32                  `json:"photo_width"`
+	PhotoHeight  int32                  `json:"photo_height"`
+	ReplyMarkup  interface{}            `json:"reply_markup"`
+	Content      map[string]interface{} `json:"input_message_content"`
+}
+
+// NewInlinePhoto returns a photo result with the given photo and thumbnail URLs.
+func NewInlinePhoto(id string, photoURL string, thumbnailURL string) *InlinePhoto {
+	return &InlinePhoto{ID: id, PhotoURL: photoURL, ThumbnailURL: thumbnailURL}
+}
+
+// InlineDocument is an inputInlineQueryResultDocument, a link to a file.
+// If Content is nil, the file itself is sent when the result is chosen.
+type InlineDocument struct {
+	ID              string                 `json:"id"`
+	Title           string                 `json:"title"`
+	Description     string                 `json:"description"`
+	DocumentURL     string                 `json:"document_url"`
+	MimeType        string                 `json:"mime_type"` // only "application/pdf" and "application/zip" are allowed
+	ThumbnailURL    string                 `json:"thumbnail_url"`
+	ThumbnailWidth  int32                  `json:"thumbnail_width"`
+	ThumbnailHeight int32                  `json:"thumbnail_height"`
+	ReplyMarkup     interface{}            `json:"reply_markup"`
+	Content         map[string]interface{} `json:"input_message_content"`
+}
+
+// NewInlineDocument returns a document result with the file at the given URL.
+func NewInlineDocument(id string, title string, documentURL string, mimeType string) *InlineDocument {
+	return &InlineDocument{ID: id, Title: title, DocumentURL: documentURL, MimeType: mimeType}
+}
+
+// InlineContact is an inputInlineQueryResultContact, a user contact.
+// If Content is nil, the contact itself is sent when the result is chosen.
+type InlineContact struct {
+	ID              string                 `json:"id"`
+	Contact         Contact                `json:"contact"`
+	ThumbnailURL    string                 `json:"thumbnail_url"`
+	ThumbnailWidth  int32                  `json:"thumbnail_width"`
+	ThumbnailHeight int32                  `json:"thumbnail_height"`
+	ReplyMarkup     interface{}            `json:"reply_markup"`
+	Content         map[string]interface{} `json:"input_message_content"`
+}
+
+// NewInlineContact returns a contact result.
+func NewInlineContact(id string, contact Contact) *InlineContact {
+	return &InlineContact{ID: id, Contact: contact}
+}
+
+// Contact represents a contact object, a user contact.
+type Contact struct {
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	Vcard       string `json:"vcard"`
+	UserID      int32  `json:"user_id"` // 0 if the user isn't known
+}
+
+// MarshalJSON serializes the contact along with its "@type".
+func (c Contact) MarshalJSON() ([]byte, error) {
+	type alias Contact
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		alias
+	}{"contact", alias(c)})
+}
+
+// MarshalJSON serializes the result along with its "@type".
+func (r *InlineArticle) MarshalJSON() ([]byte, error) {
+	type alias InlineArticle
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		*alias
+	}{"inputInlineQueryResultArticle", (*alias)(r)})
+}
+
+// MarshalJSON serializes the result along with its "@type".
+func (r *InlinePhoto) MarshalJSON() ([]byte, error) {
+	type alias InlinePhoto
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		*alias
+	}{"inputInlineQueryResultPhoto", (*alias)(r)})
+}
+
+// MarshalJSON serializes the result along with its "@type".
+func (r *InlineDocument) MarshalJSON() ([]byte, error) {
+	type alias InlineDocument
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		*alias
+	}{"inputInlineQueryResultDocument", (*alias)(r)})
+}
+
+// MarshalJSON serializes the result along with its "@type".
+func (r *InlineContact) MarshalJSON() ([]byte, error) {
+	type alias InlineContact
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		*alias
+	}{"inputInlineQueryResultContact", (*alias)(r)})
+}
+
+func (*InlineArticle) isInlineQueryResult()  {}
+func (*InlinePhoto) isInlineQueryResult()    {}
+func (*InlineDocument) isInlineQueryResult() {}
+func (*InlineContact) isInlineQueryResult()  {}
+
+// InlineQueryAnswer contains the parameters of answerInlineQuery except for the query identifier.
+type InlineQueryAnswer struct {
+	Results           []InlineQueryResult
+	IsPersonal        bool   // true, if the results can be cached only for the user, who sent the query
+	CacheTime         int32  // allowed time to cache the results, in seconds
+	NextOffset        string // offset for the next inline query; empty if there are no more results
+	SwitchPMText      string // if non-empty, text of the button, which opens a private chat with the bot
+	SwitchPMParameter string // parameter of the bot start message sent by the button
+}
+
+// AnswerInlineQuery sets the results of the inline query with the identifier from updateNewInlineQuery
+// and waits for the response. For bots only.
+func (c *Client) AnswerInlineQuery(ctx context.Context, inlineQueryID int64, answer InlineQueryAnswer) error {
+	_, err := c.Call(ctx, map[string]interface{}{
+		"@type": "answerInlineQuery",
+		// int64 values are serialized as strings
+		"inline_query_id":     strconv.FormatInt(inlineQueryID, 10),
+		"is_personal":         answer.IsPersonal,
+		"results":             answer.Results,
+		"cache_time":          answer.CacheTime,
+		"next_offset":         answer.NextOffset,
+		"switch_pm_text":      answer.SwitchPMText,
+		"switch_pm_parameter": answer.SwitchPMParameter,
+	})
+	return err
+}
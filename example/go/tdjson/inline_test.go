@@ -0,0 +1,125 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInlineArticleJSON(t *testing.T) {
+	article := NewInlineArticle("1", "TDLib", InputMessageText((&TextBuilder{}).Bold("TDLib").Plain(" is here").FormattedText(), true))
+	article.Description = "Telegram Database library"
+	data, err := json.Marshal(article)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	var want interface{}
+	if err := json.Unmarshal([]byte(`{
+		"@type": "inputInlineQueryResultArticle",
+		"id": "1",
+		"url": "",
+		"hide_url": false,
+		"title": "TDLib",
+		"description": "Telegram Database library",
+		"thumbnail_url": "",
+		"thumbnail_width": 0,
+		"thumbnail_height": 0,
+		"reply_markup": null,
+		"input_message_content": {
+			"@type": "inputMessageText",
+			"text": {
+				"@type": "formattedText",
+				"text": "TDLib is here",
+				"entities": [{"@type": "textEntity", "offset": 0, "length": 5, "type": {"@type": "textEntityTypeBold"}}]
+			},
+			"disable_web_page_preview": true,
+			"clear_draft": false
+		}
+	}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestInlineResultTypes(t *testing.T) {
+	results := []InlineQueryResult{
+		NewInlineArticle("1", "title", InputMessageText(FormattedText{Text: "text"}, false)),
+		NewInlinePhoto("2", "https://example.com/photo.jpg", "https://example.com/thumbnail.jpg"),
+		NewInlineDocument("3", "title", "https://example.com/document.pdf", "application/pdf"),
+		NewInlineContact("4", Contact{PhoneNumber: "+123456789", FirstName: "First"}),
+	}
+	types := []string{"inputInlineQueryResultArticle", "inputInlineQueryResultPhoto", "inputInlineQueryResultDocument",
+		"inputInlineQueryResultContact"}
+	for i, result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var object map[string]interface{}
+		if err := json.Unmarshal(data, &object); err != nil {
+			t.Fatal(err)
+		}
+		if object["@type"] != types[i] || object["id"] == "" {
+			t.Errorf("got %s", data)
+		}
+		if i > 0 && object["input_message_content"] != nil {
+			t.Errorf("got content in %s", data)
+		}
+	}
+
+	data, _ := json.Marshal(results[3])
+	var contact struct {
+		Contact map[string]interface{} `json:"contact"`
+	}
+	if err := json.Unmarshal(data, &contact); err != nil || contact.Contact["@type"] != "contact" ||
+		contact.Contact["phone_number"] != "+123456789" {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestAnswerInlineQuery(t *testing.T) {
+	client, td := newFakeClient(t)
+	requests := make(chan map[string]interface{}, 1)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "answerInlineQuery" {
+			requests <- request
+			td.push(clientID, map[string]interface{}{"@type": "ok", "@extra": request["@extra"]})
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := client.AnswerInlineQuery(ctx, 1234567890123456789, InlineQueryAnswer{
+		Results:    []InlineQueryResult{NewInlinePhoto("1", "https://example.com/photo.jpg", "https://example.com/thumbnail.jpg")},
+		CacheTime:  300,
+		NextOffset: "10",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := <-requests
+	if request["inline_query_id"] != "1234567890123456789" || request["cache_time"] != float64(300) ||
+		request["next_offset"] != "10" || request["is_personal"] != false {
+		t.Errorf("got request %v", request)
+	}
+	if results := request["results"].([]interface{}); len(results) != 1 ||
+		results[0].(map[string]interface{})["@type"] != "inputInlineQueryResultPhoto" {
+		t.Errorf("got results %v", results)
+	}
+}
@@ -0,0 +1,33 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Decode deserializes a TDLib object to v like json.Unmarshal, but stores numbers in interface{} values
+// as json.Number instead of float64.
+//
+// Identifiers of chats, messages and users are serialized by TDLib as JSON numbers, which don't always fit
+// into the 53-bit mantissa of a float64, so objects must be decoded either with Decode or to structs
+// with int64 fields to avoid silent corruption of the identifiers.
+func Decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("tdjson: unexpected data after the JSON object")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// event has the keys sorted, so it is serialized back byte-exactly
+const largeIDsEvent = `{"@type":"updateNewMessage","message":{"@type":"message","chat_id":-1001234567890123,` +
+	`"id":9007199254740993,"sender":{"@type":"messageSenderUser","user_id":2147483647}}}`
+
+func TestDecodeRoundTrip(t *testing.T) {
+	var object map[string]interface{}
+	if err := Decode([]byte(largeIDsEvent), &object); err != nil {
+		t.Fatal(err)
+	}
+	message := object["message"].(map[string]interface{})
+	if chatID, err := message["chat_id"].(json.Number).Int64(); err != nil || chatID != -1001234567890123 {
+		t.Errorf("got chat_id %v: %v", message["chat_id"], err)
+	}
+	if id, err := message["id"].(json.Number).Int64(); err != nil || id != 9007199254740993 {
+		t.Errorf("got id %v: %v", message["id"], err)
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != largeIDsEvent {
+		t.Errorf("round trip returned %s", data)
+	}
+
+	// json.Unmarshal rounds the identifier, which isn't representable as float64
+	var rounded map[string]interface{}
+	if err := json.Unmarshal([]byte(largeIDsEvent), &rounded); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := json.Marshal(rounded); string(data) == largeIDsEvent {
+		t.Error("expected precision loss with json.Unmarshal")
+	}
+}
+
+func TestDecodeTrailingData(t *testing.T) {
+	var object map[string]interface{}
+	if err := Decode([]byte(`{"@type":"ok"} {}`), &object); err == nil {
+		t.Error("trailing data was accepted")
+	}
+	if err := Decode([]byte(`{"@type":"ok"`), &object); err == nil {
+		t.Error("truncated object was accepted")
+	}
+}
+
+func TestDispatcherPreservesLargeIDs(t *testing.T) {
+	client, td := newFakeClient(t)
+	d := NewDispatcher(client, 4)
+	received := make(chan json.RawMessage, 1)
+	d.OnUpdate("updateNewMessage", func(update json.RawMessage) {
+		received <- append(json.RawMessage(nil), update...)
+	})
+	wait := runDispatcher(t, d)
+	defer wait()
+
+	event := `{"@client_id":1,` + largeIDsEvent[1:]
+	td.events <- event
+	update := <-received
+	if string(update) != event {
+		t.Errorf("handler received %s", update)
+	}
+	chatID := int64(-1001234567890123)
+	if index := d.workerIndex(string(update)); index != int(uint64(chatID)%4) {
+		t.Errorf("event is handled by worker %d", index)
+	}
+}
@@ -0,0 +1,77 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import "encoding/json"
+
+// InlineKeyboardButton represents an inlineKeyboardButton object, a button of an inline keyboard.
+type InlineKeyboardButton struct {
+	Text string                   `json:"text"`
+	Type InlineKeyboardButtonType `json:"type"`
+}
+
+// MarshalJSON serializes the button along with its "@type".
+func (b InlineKeyboardButton) MarshalJSON() ([]byte, error) {
+	type alias InlineKeyboardButton
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		alias
+	}{"inlineKeyboardButton", alias(b)})
+}
+
+// InlineKeyboardButtonType represents any of inlineKeyboardButtonType* objects. Fields, which aren't used
+// by the type, are empty.
+type InlineKeyboardButtonType struct {
+	Type          string `json:"@type"`
+	URL           string `json:"url,omitempty"`             // inlineKeyboardButtonTypeUrl
+	Data          []byte `json:"data,omitempty"`            // inlineKeyboardButtonTypeCallback; serialized in base64
+	Query         string `json:"query,omitempty"`           // inlineKeyboardButtonTypeSwitchInline
+	InCurrentChat bool   `json:"in_current_chat,omitempty"` // inlineKeyboardButtonTypeSwitchInline
+}
+
+// CallbackButton returns a button, which sends updateNewCallbackQuery with the data to the bot when pressed.
+// The data is received in callbackQueryPayloadData and must not be longer than 64 bytes.
+func CallbackButton(text string, data []byte) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, Type: InlineKeyboardButtonType{Type: "inlineKeyboardButtonTypeCallback", Data: data}}
+}
+
+// URLButton returns a button, which opens the URL when pressed.
+func URLButton(text string, url string) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, Type: InlineKeyboardButtonType{Type: "inlineKeyboardButtonTypeUrl", URL: url}}
+}
+
+// SwitchInlineButton returns a button, which makes the user choose a chat and starts an inline query to the bot
+// with the given query in it. If inCurrentChat is true, the inline query is started in the current chat instead.
+func SwitchInlineButton(text string, query string, inCurrentChat bool) InlineKeyboardButton {
+	return InlineKeyboardButton{Text: text, Type: InlineKeyboardButtonType{Type: "inlineKeyboardButtonTypeSwitchInline",
+		Query: query, InCurrentChat: inCurrentChat}}
+}
+
+// Keyboard builds an inline keyboard row by row. It is serialized to a replyMarkupInlineKeyboard object,
+// so it can be passed as reply_markup of a request.
+type Keyboard struct {
+	rows [][]InlineKeyboardButton
+}
+
+// Row appends a row with the buttons to the keyboard.
+func (k *Keyboard) Row(buttons ...InlineKeyboardButton) *Keyboard {
+	k.rows = append(k.rows, append([]InlineKeyboardButton{}, buttons...))
+	return k
+}
+
+// MarshalJSON serializes the keyboard as a replyMarkupInlineKeyboard object.
+func (k *Keyboard) MarshalJSON() ([]byte, error) {
+	rows := k.rows
+	if rows == nil {
+		rows = [][]InlineKeyboardButton{}
+	}
+	return json.Marshal(struct {
+		Type string                   `json:"@type"`
+		Rows [][]InlineKeyboardButton `json:"rows"`
+	}{"replyMarkupInlineKeyboard", rows})
+}
@@ -0,0 +1,90 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/tdlib/td/example/go/tdapi"
+)
+
+func TestKeyboardJSON(t *testing.T) {
+	keyboard := (&Keyboard{}).
+		Row(CallbackButton("Yes", []byte("answer:yes")), CallbackButton("No", []byte{0, 0xff, 0xfe})).
+		Row(URLButton("Open", "https://telegram.org")).
+		Row(SwitchInlineButton("Share", "query", false), SwitchInlineButton("Here", "", true))
+	data, err := json.Marshal(keyboard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	var want interface{}
+	if err := json.Unmarshal([]byte(`{"@type": "replyMarkupInlineKeyboard", "rows": [
+		[
+			{"@type": "inlineKeyboardButton", "text": "Yes", "type": {"@type": "inlineKeyboardButtonTypeCallback", "data": "YW5zd2VyOnllcw=="}},
+			{"@type": "inlineKeyboardButton", "text": "No", "type": {"@type": "inlineKeyboardButtonTypeCallback", "data": "AP/+"}}
+		],
+		[
+			{"@type": "inlineKeyboardButton", "text": "Open", "type": {"@type": "inlineKeyboardButtonTypeUrl", "url": "https://telegram.org"}}
+		],
+		[
+			{"@type": "inlineKeyboardButton", "text": "Share", "type": {"@type": "inlineKeyboardButtonTypeSwitchInline", "query": "query"}},
+			{"@type": "inlineKeyboardButton", "text": "Here", "type": {"@type": "inlineKeyboardButtonTypeSwitchInline", "in_current_chat": true}}
+		]
+	]}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %s", data)
+	}
+
+	// the keyboard must be accepted by TDLib
+	request, err := json.Marshal(map[string]interface{}{"@type": "editMessageReplyMarkup", "chat_id": testChatID,
+		"message_id": 1, "reply_markup": keyboard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tdapi.ValidateRequest(request); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyboardCallbackData(t *testing.T) {
+	payload := []byte{0, 1, 2, 0x80, 0xff, '+', '/', '='}
+	data, err := json.Marshal(CallbackButton("Button", payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var button struct {
+		Type struct {
+			Data string `json:"data"`
+		} `json:"type"`
+	}
+	if err := json.Unmarshal(data, &button); err != nil {
+		t.Fatal(err)
+	}
+	// TDLib expects the standard base64 encoding with padding
+	decoded, err := base64.StdEncoding.DecodeString(button.Type.Data)
+	if err != nil || !reflect.DeepEqual(decoded, payload) {
+		t.Errorf("got data %q", button.Type.Data)
+	}
+}
+
+func TestEmptyKeyboard(t *testing.T) {
+	data, err := json.Marshal(&Keyboard{})
+	if err != nil || string(data) != `{"@type":"replyMarkupInlineKeyboard","rows":[]}` {
+		t.Errorf("got %s", data)
+	}
+}
@@ -0,0 +1,180 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter limits the rate of sending messages. It is consulted by Call before sending of requests,
+// which send messages to a chat, and by methods using Call, for example SendMessageAndWait.
+type Limiter interface {
+	// Wait blocks until a message can be sent to the chat or the context is done.
+	Wait(ctx context.Context, chatID int64) error
+}
+
+// limitedRequests are the requests sending messages to the chat specified in their chat_id.
+var limitedRequests = map[string]bool{
+	"sendMessage":                  true,
+	"sendMessageAlbum":             true,
+	"sendInlineQueryResultMessage": true,
+	"forwardMessages":              true,
+}
+
+// SetLimiter sets the Limiter consulted before sending of messages. Requests sent directly by Send and SendBatch
+// aren't limited. Passing nil disables the limits.
+func (c *Client) SetLimiter(limiter Limiter) {
+	c.mu.Lock()
+	c.limiter = limiter
+	c.mu.Unlock()
+}
+
+// waitLimiter waits until the request can be sent according to the Limiter of the client.
+func (c *Client) waitLimiter(ctx context.Context, query map[string]interface{}) error {
+	c.mu.RLock()
+	limiter := c.limiter
+	c.mu.RUnlock()
+	if limiter == nil {
+		return nil
+	}
+	if typ, _ := query["@type"].(string); !limitedRequests[typ] {
+		return nil
+	}
+	var chatID int64
+	switch id := query["chat_id"].(type) {
+	case int64:
+		chatID = id
+	case int:
+		chatID = int64(id)
+	case int32:
+		chatID = int64(id)
+	case float64:
+		chatID = int64(id)
+	}
+	return limiter.Wait(ctx, chatID)
+}
+
+// ChatLimiter is a Limiter, which uses a token bucket per chat and a global token bucket.
+// Telegram allows bots to send about 1 message per second to a chat and about 30 messages per second in total,
+// so NewChatLimiter(1, 30) can be used to avoid flood wait errors proactively.
+type ChatLimiter struct {
+	mu      sync.Mutex
+	now     func() time.Time                                 // replaced in tests
+	sleep   func(ctx context.Context, d time.Duration) error // replaced in tests
+	chat    bucket                                           // parameters of buckets of chats
+	chats   map[int64]*bucket
+	global  *bucket
+	sweepAt int // size of chats, after which full buckets are removed
+}
+
+// bucket is a token bucket implemented as the generic cell rate algorithm, so it is described
+// by the time at which it becomes full instead of the number of tokens in it.
+type bucket struct {
+	interval  time.Duration // time to refill one token
+	tolerance time.Duration // time to refill the bucket with all tokens but one
+	full      time.Time     // time at which the bucket becomes full
+}
+
+func newBucket(rate float64, burst int) bucket {
+	interval := time.Duration(float64(time.Second) / rate)
+	return bucket{interval: interval, tolerance: time.Duration(burst-1) * interval}
+}
+
+// earliest returns the earliest time not before now at which a token is available.
+func (b *bucket) earliest(now time.Time) time.Time {
+	if at := b.full.Add(-b.tolerance); at.After(now) {
+		return at
+	}
+	return now
+}
+
+// take takes a token at the given time, which must not be earlier than returned by earliest.
+// The times passed to take must not decrease.
+func (b *bucket) take(at time.Time) {
+	if b.full.Before(at) {
+		b.full = at
+	}
+	b.full = b.full.Add(b.interval)
+}
+
+// NewChatLimiter returns a ChatLimiter allowing chatRate messages per second to every chat and globalRate
+// messages per second in total. Up to globalRate messages can be sent at once to different chats.
+// Zero globalRate disables the global limit.
+func NewChatLimiter(chatRate float64, globalRate float64) *ChatLimiter {
+	l := &ChatLimiter{now: time.Now, sleep: sleepContext, chat: newBucket(chatRate, 1), chats: make(map[int64]*bucket), sweepAt: 64}
+	if globalRate > 0 {
+		burst := int(globalRate)
+		if burst < 1 {
+			burst = 1
+		}
+		global := newBucket(globalRate, burst)
+		l.global = &global
+	}
+	return l
+}
+
+// Wait blocks until a message can be sent to the chat or the context is done.
+func (l *ChatLimiter) Wait(ctx context.Context, chatID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for {
+		// tokens aren't reserved in advance, so waiting for one chat doesn't delay messages to other chats
+		at, ok := l.take(chatID)
+		if ok {
+			return nil
+		}
+		if err := l.sleep(ctx, at.Sub(l.now())); err != nil {
+			return err
+		}
+	}
+}
+
+// take takes tokens from the buckets if they are available now.
+// Otherwise, it returns the earliest time at which they can become available.
+func (l *ChatLimiter) take(chatID int64) (time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if len(l.chats) >= l.sweepAt {
+		l.sweep(now)
+	}
+
+	chat := l.chats[chatID]
+	if chat == nil {
+		chat = new(bucket)
+		*chat = l.chat
+		l.chats[chatID] = chat
+	}
+	at := chat.earliest(now)
+	if l.global != nil {
+		if globalAt := l.global.earliest(now); globalAt.After(at) {
+			at = globalAt
+		}
+	}
+	if at.After(now) {
+		return at, false
+	}
+	chat.take(now)
+	if l.global != nil {
+		l.global.take(now)
+	}
+	return now, true
+}
+
+// sweep removes full buckets, which are equivalent to new buckets.
+func (l *ChatLimiter) sweep(now time.Time) {
+	for chatID, chat := range l.chats {
+		if !chat.full.After(now) {
+			delete(l.chats, chatID)
+		}
+	}
+	l.sweepAt = 2*len(l.chats) + 64
+}
@@ -0,0 +1,142 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChatLimiterTake(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	now := start
+	l := NewChatLimiter(1, 3)
+	l.now = func() time.Time { return now }
+	take := func(chatID int64, wantOK bool, want time.Duration) {
+		t.Helper()
+		at, ok := l.take(chatID)
+		if ok != wantOK || at.Sub(start) != want {
+			t.Fatalf("chat %d: got %v, %v, want %v, %v", chatID, at.Sub(start), ok, want, wantOK)
+		}
+	}
+
+	take(1, true, 0)
+	take(1, false, time.Second)
+	// a message to another chat isn't delayed by the chat
+	take(2, true, 0)
+	take(3, true, 0)
+	// global limit of 3 messages at once is reached
+	take(4, false, time.Second/3)
+	now = start.Add(time.Second / 3)
+	take(4, true, time.Second/3)
+	take(5, false, 2*time.Second/3)
+
+	// the buckets are refilled over time
+	now = start.Add(time.Minute)
+	for chatID := int64(1); chatID <= 3; chatID++ {
+		take(chatID, true, time.Minute)
+	}
+}
+
+func TestChatLimiterSweep(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	l := NewChatLimiter(1, 0)
+	l.now = func() time.Time { return now }
+	for chatID := int64(0); chatID < 1000; chatID++ {
+		if _, ok := l.take(chatID); !ok {
+			t.Fatalf("chat %d is limited", chatID)
+		}
+		now = now.Add(100 * time.Millisecond)
+	}
+	// only buckets of chats with messages during the last second must be kept
+	if len(l.chats) > 100 {
+		t.Errorf("got %d buckets", len(l.chats))
+	}
+}
+
+func TestChatLimiterWait(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	var sleeps []time.Duration
+	l := NewChatLimiter(2, 0)
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sleeps) != 2 || sleeps[0] != time.Second/2 || sleeps[1] != time.Second/2 {
+		t.Errorf("got sleeps %v, want [500ms 500ms]", sleeps)
+	}
+}
+
+func TestChatLimiterWaitCanceled(t *testing.T) {
+	l := NewChatLimiter(0.01, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := l.Wait(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := l.Wait(ctx, 1); err != context.Canceled {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestCallLimiter(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "close" {
+			td.push(clientID, withExtra(messageObject(1, int64(request["chat_id"].(float64)), nil), request))
+		}
+	})
+	const interval = 100 * time.Millisecond
+	client.SetLimiter(NewChatLimiter(float64(time.Second/interval), 0))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sendAll := func(chatIDs []int64) time.Duration {
+		start := time.Now()
+		var wg sync.WaitGroup
+		for _, chatID := range chatIDs {
+			wg.Add(1)
+			go func(chatID int64) {
+				defer wg.Done()
+				if _, err := client.SendMessageAndWait(ctx, chatID, textContent("text")); err != nil {
+					t.Error(err)
+				}
+			}(chatID)
+		}
+		wg.Wait()
+		return time.Since(start)
+	}
+
+	// a burst to one chat is spaced out
+	if d := sendAll([]int64{testChatID, testChatID, testChatID}); d < 2*interval {
+		t.Errorf("messages to one chat were sent in %v", d)
+	}
+	// messages to different chats are sent in parallel
+	if d := sendAll([]int64{1, 2, 3, 4, 5}); d >= interval {
+		t.Errorf("messages to different chats were sent in %v", d)
+	}
+	// other requests aren't limited
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := client.Call(ctx, map[string]interface{}{"@type": "getChat", "chat_id": testChatID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d >= interval {
+		t.Errorf("requests were limited for %v", d)
+	}
+}
@@ -0,0 +1,118 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+/*
+#include <td/telegram/td_json_client.h>
+
+void tdjsonLogMessageCallback(int verbosity_level, char *message);
+
+static void tdjson_set_log_message_callback(int max_verbosity_level, int is_enabled) {
+  td_set_log_message_callback(max_verbosity_level,
+                              is_enabled ? (td_log_message_callback_ptr)tdjsonLogMessageCallback : NULL);
+}
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+)
+
+// LogLevel is a verbosity level of the internal TDLib log.
+type LogLevel int
+
+// Verbosity levels of the internal TDLib log. Levels greater than LogVerbose and up to 1023 enable even more logging.
+const (
+	LogFatal   LogLevel = 0
+	LogError   LogLevel = 1
+	LogWarning LogLevel = 2
+	LogInfo    LogLevel = 3
+	LogDebug   LogLevel = 4
+	LogVerbose LogLevel = 5
+)
+
+// execute synchronously executes a request. It is replaced in tests.
+var execute = Execute
+
+// executeRequest serializes the request to JSON and executes it synchronously.
+func executeRequest(query map[string]interface{}) error {
+	data, err := json.Marshal(query)
+	if err != nil {
+		return err
+	}
+	_, err = execute(string(data))
+	return err
+}
+
+// SetLogLevel sets the verbosity level of the internal TDLib log.
+// The level should be set before any client is created to suppress logging of the initialization.
+func SetLogLevel(level LogLevel) error {
+	return executeRequest(map[string]interface{}{"@type": "setLogVerbosityLevel", "new_verbosity_level": int(level)})
+}
+
+// SetDefaultLogStream makes TDLib write its log to stderr, which is the default.
+func SetDefaultLogStream() error {
+	return setLogStream(map[string]interface{}{"@type": "logStreamDefault"})
+}
+
+// SetFileLogStream makes TDLib write its log to the file at the given path. When the file grows bigger than
+// maxFileSize bytes, it is renamed to path + ".old", replacing the previous one, and a new file is started,
+// so the log never takes more than about 2 * maxFileSize bytes. maxFileSize must be positive.
+// If redirectStderr is true, stderr of the process is redirected to the file too.
+func SetFileLogStream(path string, maxFileSize int64, redirectStderr bool) error {
+	return setLogStream(map[string]interface{}{
+		"@type":           "logStreamFile",
+		"path":            path,
+		"max_file_size":   maxFileSize,
+		"redirect_stderr": redirectStderr,
+	})
+}
+
+// SetEmptyLogStream disables the internal TDLib log. Log messages are still passed to the callback
+// set by SetLogMessageCallback.
+func SetEmptyLogStream() error {
+	return setLogStream(map[string]interface{}{"@type": "logStreamEmpty"})
+}
+
+func setLogStream(logStream map[string]interface{}) error {
+	return executeRequest(map[string]interface{}{"@type": "setLogStream", "log_stream": logStream})
+}
+
+// logMessageCallback contains the func(verbosity int, message string) passed to SetLogMessageCallback.
+var logMessageCallback atomic.Value
+
+// logMessageCallbackMu serializes calls to SetLogMessageCallback.
+var logMessageCallbackMu sync.Mutex
+
+// SetLogMessageCallback sets the callback that will be called when a message is added to the internal TDLib log
+// with a verbosity level not greater than maxVerbosity. Pass nil to remove the callback.
+//
+// The callback can be called simultaneously from different threads, so it must be safe for concurrent use.
+// None of the TDLib methods can be called from the callback. If the verbosity level is 0,
+// then TDLib will crash as soon as the callback returns.
+func SetLogMessageCallback(maxVerbosity int, cb func(verbosity int, message string)) {
+	logMessageCallbackMu.Lock()
+	defer logMessageCallbackMu.Unlock()
+
+	if cb == nil {
+		C.tdjson_set_log_message_callback(0, 0)
+		logMessageCallback.Store((func(int, string))(nil))
+		return
+	}
+	logMessageCallback.Store(cb)
+	C.tdjson_set_log_message_callback(C.int(maxVerbosity), 1)
+}
+
+// logMessage passes a message from the TDLib log to the current callback.
+func logMessage(verbosity int, message string) {
+	if cb, _ := logMessageCallback.Load().(func(int, string)); cb != nil {
+		cb(verbosity, message)
+	}
+}
@@ -0,0 +1,19 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+// The exported function is kept in a separate file, because a cgo preamble
+// of a file with //export directives can't contain definitions.
+
+// #include <stdlib.h>
+import "C"
+
+//export tdjsonLogMessageCallback
+func tdjsonLogMessageCallback(verbosityLevel C.int, message *C.char) {
+	logMessage(int(verbosityLevel), C.GoString(message))
+}
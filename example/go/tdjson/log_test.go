@@ -0,0 +1,161 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLogMessageCallback(t *testing.T) {
+	var count int64
+	SetLogMessageCallback(2, func(verbosity int, message string) {
+		if verbosity != 1 || message != "test" {
+			t.Errorf("got message %q with verbosity %d", message, verbosity)
+		}
+		atomic.AddInt64(&count, 1)
+	})
+
+	const goroutineCount = 8
+	const messageCount = 100
+	var wg sync.WaitGroup
+	for i := 0; i < goroutineCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < messageCount; j++ {
+				logMessage(1, "test")
+			}
+		}()
+	}
+	wg.Wait()
+	if count != goroutineCount*messageCount {
+		t.Errorf("callback was called %d times, want %d", count, goroutineCount*messageCount)
+	}
+
+	SetLogMessageCallback(0, nil)
+	logMessage(1, "test")
+	if count != goroutineCount*messageCount {
+		t.Error("callback was called after it was removed")
+	}
+}
+
+// captureExecute replaces execute with a function recording the requests, which are returned by the returned function.
+func captureExecute(t *testing.T) func() []string {
+	var requests []string
+	t.Cleanup(func() { execute = Execute })
+	execute = func(request string) (string, error) {
+		requests = append(requests, request)
+		return `{"@type":"ok"}`, nil
+	}
+	return func() []string { return requests }
+}
+
+func TestSetLogLevel(t *testing.T) {
+	requests := captureExecute(t)
+	levels := []LogLevel{LogFatal, LogError, LogWarning, LogInfo, LogDebug, LogVerbose, LogVerbose + 10}
+	for _, level := range levels {
+		if err := SetLogLevel(level); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{
+		`{"@type":"setLogVerbosityLevel","new_verbosity_level":0}`,
+		`{"@type":"setLogVerbosityLevel","new_verbosity_level":1}`,
+		`{"@type":"setLogVerbosityLevel","new_verbosity_level":2}`,
+		`{"@type":"setLogVerbosityLevel","new_verbosity_level":3}`,
+		`{"@type":"setLogVerbosityLevel","new_verbosity_level":4}`,
+		`{"@type":"setLogVerbosityLevel","new_verbosity_level":5}`,
+		`{"@type":"setLogVerbosityLevel","new_verbosity_level":15}`,
+	}
+	if got := requests(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got requests %q", got)
+	}
+}
+
+func TestSetLogStream(t *testing.T) {
+	requests := captureExecute(t)
+	if err := SetEmptyLogStream(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetDefaultLogStream(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`{"@type":"setLogStream","log_stream":{"@type":"logStreamEmpty"}}`,
+		`{"@type":"setLogStream","log_stream":{"@type":"logStreamDefault"}}`,
+	}
+	if got := requests(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got requests %q", got)
+	}
+}
+
+func TestSetLogLevelError(t *testing.T) {
+	t.Cleanup(func() { execute = Execute })
+	execute = func(request string) (string, error) {
+		result := `{"@type":"error","code":400,"message":"Wrong new verbosity level specified"}`
+		return result, responseError([]byte(result))
+	}
+	var tdErr *Error
+	if err := SetLogLevel(-1); !errors.As(err, &tdErr) || tdErr.Code != 400 {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestSetFileLogStreamRequest(t *testing.T) {
+	requests := captureExecute(t)
+	if err := SetFileLogStream("/var/log/tdlib.log", 1<<20, true); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{`{"@type":"setLogStream","log_stream":{"@type":"logStreamFile","max_file_size":1048576,` +
+		`"path":"/var/log/tdlib.log","redirect_stderr":true}}`}
+	if got := requests(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got requests %q", got)
+	}
+}
+
+func TestSetFileLogStreamRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tdlib.log")
+	const maxFileSize = 4096
+	if err := SetFileLogStream(path, maxFileSize, false); err != nil {
+		t.Fatal(err)
+	}
+	defer SetDefaultLogStream()
+	if err := SetLogLevel(LogError); err != nil {
+		t.Fatal(err)
+	}
+
+	line := strings.Repeat("x", 100)
+	for i := 0; i < 100; i++ {
+		request := `{"@type":"addLogMessage","verbosity_level":1,"text":"` + line + `"}`
+		if _, err := Execute(request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the file is rotated before a message is written to a file, which is already bigger than maxFileSize
+	if info.Size() == 0 || info.Size() > maxFileSize+2*int64(len(line))+200 {
+		t.Errorf("log file has size %d", info.Size())
+	}
+	if _, err := os.Stat(path + ".old"); err != nil {
+		t.Errorf("log file wasn't rotated: %v", err)
+	}
+}
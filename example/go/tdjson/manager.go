@@ -0,0 +1,283 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+/*
+#include <stdlib.h>
+#include <td/telegram/td_json_client.h>
+*/
+import "C"
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// defaultReceiveTimeout is the default maximum time for which the receive loop waits in td_receive.
+const defaultReceiveTimeout = time.Second
+
+// isManagerActive is set while a Manager exists, because td_receive returns events for all clients in the process.
+var isManagerActive int32
+
+// backend is the td_create_client_id interface of TDLib. It is replaced with a fake in tests.
+type backend interface {
+	createClientID() int
+	send(clientID int, request string)
+	sendBatch(clientID int, requests []string)
+	receive(timeout float64) (string, bool)
+}
+
+type tdBackend struct{}
+
+func (tdBackend) createClientID() int {
+	return int(C.td_create_client_id())
+}
+
+func (tdBackend) send(clientID int, request string) {
+	// the request is copied by TDLib before td_send returns, so it can be freed immediately
+	cRequest := C.CString(request)
+	defer C.free(unsafe.Pointer(cRequest))
+	C.td_send(C.int(clientID), cRequest)
+}
+
+// receive returns false as the second value if no event was received before the timeout expired.
+// It must be called from a goroutine locked to its thread, see Execute.
+func (tdBackend) receive(timeout float64) (string, bool) {
+	result := C.td_receive(C.double(timeout))
+	if result == nil {
+		return "", false
+	}
+	return C.GoString(result), true
+}
+
+// Manager manages TDLib clients created through the td_create_client_id interface.
+// It owns the only goroutine calling td_receive and routes received events to the corresponding clients
+// by their "@client_id" field, so any number of clients can share one receive loop.
+// Only one Manager can exist at a time.
+type Manager struct {
+	td  backend
+	ctx context.Context
+
+	mu      sync.Mutex
+	clients map[int]*Client
+	err     error // set when the receive loop stops, pending calls of all clients are aborted with it
+
+	timeout receiveTimeout
+
+	exclusive bool // the Manager owns isManagerActive
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// receiveTimeout chooses the timeout for the next call to td_receive.
+// If minTimeout < maxTimeout, the timeout is doubled after each call returning no event up to maxTimeout
+// and is reset to minTimeout after each received event.
+type receiveTimeout struct {
+	mu         sync.Mutex
+	minTimeout time.Duration
+	maxTimeout time.Duration
+	current    time.Duration
+}
+
+func (t *receiveTimeout) set(minTimeout time.Duration, maxTimeout time.Duration) {
+	if maxTimeout < minTimeout {
+		maxTimeout = minTimeout
+	}
+	t.mu.Lock()
+	t.minTimeout = minTimeout
+	t.maxTimeout = maxTimeout
+	t.current = minTimeout
+	t.mu.Unlock()
+}
+
+func (t *receiveTimeout) get() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current
+}
+
+// update adapts the timeout to the result of the last call to td_receive.
+func (t *receiveTimeout) update(received bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if received {
+		t.current = t.minTimeout
+		return
+	}
+	t.current *= 2
+	if t.current > t.maxTimeout || t.current <= 0 {
+		t.current = t.maxTimeout
+	}
+}
+
+// NewManager creates a Manager and starts its receive loop.
+// It panics if another Manager wasn't closed.
+func NewManager() *Manager {
+	return NewManagerContext(context.Background())
+}
+
+// NewManagerContext is like NewManager, but the Manager stops when the context is done.
+// Then pending Call requests of its clients fail with the context error immediately and subsequent sends
+// return the context error, while the receive loop stops after the current call to td_receive returns.
+// Close must still be called to wait for the receive loop to stop.
+func NewManagerContext(ctx context.Context) *Manager {
+	if !atomic.CompareAndSwapInt32(&isManagerActive, 0, 1) {
+		panic("tdjson: only one Manager can exist at a time")
+	}
+	return startManager(ctx, tdBackend{}, true)
+}
+
+func newManager(ctx context.Context, td backend) *Manager {
+	return startManager(ctx, td, false)
+}
+
+func startManager(ctx context.Context, td backend, exclusive bool) *Manager {
+	m := &Manager{
+		td:        td,
+		ctx:       ctx,
+		clients:   make(map[int]*Client),
+		exclusive: exclusive,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	m.timeout.set(defaultReceiveTimeout, defaultReceiveTimeout)
+	go m.run()
+	go func() {
+		// pending calls fail as soon as the context is done, without waiting for the receive loop to stop
+		select {
+		case <-ctx.Done():
+			m.abortCalls(ctx.Err())
+		case <-m.done:
+		}
+	}()
+	return m
+}
+
+// SetReceiveTimeout sets the maximum time for which the receive loop waits for a new event in td_receive.
+// Longer timeouts reduce the number of wakeups of an idle receive loop, but Close may have to wait
+// for the timeout before it returns. The default timeout is 1 second.
+func (m *Manager) SetReceiveTimeout(timeout time.Duration) {
+	m.timeout.set(timeout, timeout)
+}
+
+// SetAdaptiveReceiveTimeout makes the receive loop wait in td_receive for minTimeout while events are being received
+// and double the timeout up to maxTimeout every time no event is received, so an idle loop wakes up rarely.
+// The timeout takes effect from the next call to td_receive.
+func (m *Manager) SetAdaptiveReceiveTimeout(minTimeout time.Duration, maxTimeout time.Duration) {
+	m.timeout.set(minTimeout, maxTimeout)
+}
+
+// NewClient creates a new TDLib instance. The instance will not send updates until the first request is sent to it.
+//
+// Updates and responses to requests not sent by Call are queued for the client without a limit until they are
+// returned by Receive, so Receive must be called regularly, for example by a Dispatcher, even if the client
+// is used only through Call and the helpers based on it.
+func (m *Manager) NewClient() *Client {
+	c := &Client{
+		manager:  m,
+		clientID: m.td.createClientID(),
+		events:   newEventQueue(),
+		done:     make(chan struct{}),
+		closed:   make(chan struct{}),
+		me:       newMeCache(),
+	}
+
+	m.mu.Lock()
+	m.clients[c.clientID] = c
+	if m.err != nil {
+		c.calls.abort(m.err)
+	}
+	m.mu.Unlock()
+	return c
+}
+
+// Close stops the receive loop after the current call to td_receive returns, which may take up to the receive timeout.
+// Events received afterwards aren't delivered to clients of the Manager, so their pending and subsequent
+// Call requests fail with ErrClientClosed.
+func (m *Manager) Close() {
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
+	}
+	<-m.done
+}
+
+// abortCalls makes pending and subsequent Call requests of all clients fail with the error,
+// because their responses will never be received.
+func (m *Manager) abortCalls(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err == nil {
+		m.err = err
+	}
+	for _, c := range m.clients {
+		c.calls.abort(m.err)
+	}
+}
+
+func (m *Manager) removeClient(clientID int) {
+	m.mu.Lock()
+	delete(m.clients, clientID)
+	m.mu.Unlock()
+}
+
+func (m *Manager) run() {
+	// the receive loop owns a dedicated thread, because results of td_receive are stored in a thread-local buffer
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer func() {
+		// the flag is cleared before Close returns, so a new Manager can be created right after it
+		if m.exclusive {
+			atomic.StoreInt32(&isManagerActive, 0)
+		}
+		close(m.done)
+	}()
+	defer func() {
+		if err := m.ctx.Err(); err != nil {
+			m.abortCalls(err)
+		} else {
+			m.abortCalls(ErrClientClosed)
+		}
+	}()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-m.ctx.Done():
+			return
+		default:
+		}
+
+		event, ok := m.td.receive(m.timeout.get().Seconds())
+		m.timeout.update(ok)
+		if ok {
+			m.route(event)
+		}
+	}
+}
+
+func (m *Manager) route(event string) {
+	header, ok := parseEventHeader(event)
+	if !ok {
+		return
+	}
+
+	m.mu.Lock()
+	c := m.clients[header.ClientID]
+	m.mu.Unlock()
+	if c != nil && !c.process(event, &header) {
+		c.events.push(event)
+	}
+}
@@ -0,0 +1,222 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestReceiveTimeoutAdaptive(t *testing.T) {
+	var timeout receiveTimeout
+	timeout.set(10*time.Millisecond, 50*time.Millisecond)
+
+	var got []time.Duration
+	for _, received := range []bool{false, false, false, false, true, false, true, true} {
+		got = append(got, timeout.get())
+		timeout.update(received)
+	}
+	expected := []time.Duration{10, 20, 40, 50, 50, 10, 20, 10}
+	for i := range expected {
+		if got[i] != expected[i]*time.Millisecond {
+			t.Fatalf("got timeouts %v, expected %v ms", got, expected)
+		}
+	}
+}
+
+func TestReceiveTimeoutFixed(t *testing.T) {
+	var timeout receiveTimeout
+	timeout.set(time.Second, time.Second)
+	for _, received := range []bool{false, true, false} {
+		timeout.update(received)
+		if timeout.get() != time.Second {
+			t.Fatalf("got timeout %v", timeout.get())
+		}
+	}
+}
+
+func TestManagerAdaptiveReceiveTimeout(t *testing.T) {
+	td := newFakeBackend()
+	m := newManager(context.Background(), td)
+	defer m.Close()
+	c := m.NewClient()
+	defer c.Destroy()
+	m.SetAdaptiveReceiveTimeout(10*time.Millisecond, 80*time.Millisecond)
+
+	// wait until the timeout grows to the maximum while no events are received
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		timeouts := td.receiveTimeouts()
+		if n := len(timeouts); n > 0 && timeouts[n-1] == 0.08 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout didn't grow: %v", timeouts)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	td.push(c.clientID, map[string]interface{}{"@type": "updateOption"})
+	if event, _ := c.Receive(5 * time.Second); event == "" {
+		t.Fatal("event wasn't received")
+	}
+	// the call returning the event is followed by a call with the minimum timeout
+	for {
+		timeouts := td.receiveTimeouts()
+		for i := 1; i < len(timeouts); i++ {
+			if timeouts[i-1] == 0.08 && timeouts[i] == 0.01 {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout didn't shrink: %v", timeouts)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestManagerReceiveTimeoutIsNotAnEvent(t *testing.T) {
+	td := newFakeBackend()
+	// if the result of a timed out receive was treated as an event, it would be delivered to the client
+	td.timedOut = `{"@type":"updateOption","@client_id":1}`
+	m := newManager(context.Background(), td)
+	defer m.Close()
+	c := m.NewClient()
+	defer c.Destroy()
+
+	event, err := c.Receive(100 * time.Millisecond)
+	if event != "" || err != nil {
+		t.Fatalf("got event %q and error %v", event, err)
+	}
+	if len(td.receiveTimeouts()) < 2 {
+		t.Fatal("receive wasn't called repeatedly")
+	}
+	if stats := c.Stats(); stats.EventsReceived != 0 {
+		t.Errorf("got stats %+v", stats)
+	}
+
+	td.events <- `{"@type":"updateOption","@client_id":1,"name":"version"}`
+	if event, _ := c.Receive(5 * time.Second); event != `{"@type":"updateOption","@client_id":1,"name":"version"}` {
+		t.Errorf("got event %q", event)
+	}
+}
+
+func TestReceiveTimeoutLegacy(t *testing.T) {
+	if Version() != "" {
+		t.Skip("the client may receive events from a real TDLib")
+	}
+	c := NewClient()
+	defer c.Destroy()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if event, ok := c.receive(0.01); ok || event != "" {
+		t.Errorf("got event %q", event)
+	}
+}
+
+func TestManagerContextCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	td := newFakeBackend()
+	sent := make(chan struct{}, 1)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "getMe" {
+			sent <- struct{}{}
+		}
+	})
+	m := newManager(ctx, td)
+	defer m.Close()
+	c := m.NewClient()
+	defer c.Destroy()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Call(context.Background(), map[string]interface{}{"@type": "getMe"})
+		done <- err
+	}()
+	<-sent
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("pending call returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending call wasn't canceled")
+	}
+	select {
+	case <-m.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("receive loop didn't stop")
+	}
+
+	if err := c.Send(`{"@type":"getMe"}`); err != context.Canceled {
+		t.Errorf("Send returned %v", err)
+	}
+	if _, err := c.Call(context.Background(), map[string]interface{}{"@type": "getMe"}); err != context.Canceled {
+		t.Errorf("Call returned %v", err)
+	}
+	if _, err := c.Receive(time.Millisecond); err != context.Canceled {
+		t.Errorf("Receive returned %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d goroutines instead of %d", runtime.NumGoroutine(), baseline)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestManagerCloseAbortsCalls(t *testing.T) {
+	td := newFakeBackend()
+	m := newManager(context.Background(), td)
+	c := m.NewClient()
+	defer c.Destroy()
+	done := startPendingCall(t, c, td, func(int) {})
+
+	next := startPendingNext(c)
+
+	m.Close()
+	waitError(t, done, ErrClientClosed)
+	waitError(t, next, ErrClientClosed)
+	waitError(t, next, ErrClientClosed)
+
+	// clients created after the receive loop stopped can't receive responses either
+	other := m.NewClient()
+	defer other.Destroy()
+	if _, err := other.Call(context.Background(), map[string]interface{}{"@type": "getMe"}); err != ErrClientClosed {
+		t.Errorf("Call of a new client returned %v", err)
+	}
+}
+
+func TestManagerCloseStopsClientClose(t *testing.T) {
+	td := newFakeBackend()
+	m := newManager(context.Background(), td)
+	c := m.NewClient()
+	m.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Close(context.Background())
+	}()
+	waitError(t, done, ErrClientClosed)
+}
+
+func TestManagerRecreate(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		m := NewManager()
+		m.SetReceiveTimeout(10 * time.Millisecond)
+		m.Close()
+	}
+}
@@ -0,0 +1,143 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// User is a user. Only the most commonly used fields are decoded.
+type User struct {
+	ID          int64           `json:"id"`
+	FirstName   string          `json:"first_name"`
+	LastName    string          `json:"last_name"`
+	Username    string          `json:"username"`
+	PhoneNumber string          `json:"phone_number"`
+	Type        json.RawMessage `json:"type"`
+}
+
+// meCache caches the current user. It is reset when the authorization state changes.
+type meCache struct {
+	fetch chan struct{} // held while getMe is being sent, so it is sent once
+
+	mu         sync.Mutex
+	user       *User
+	myID       int64 // value of the option "my_id"
+	generation int   // incremented by reset
+}
+
+func newMeCache() meCache {
+	return meCache{fetch: make(chan struct{}, 1)}
+}
+
+func (m *meCache) get() (*User, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.user, m.generation
+}
+
+// set caches the user, unless the cache was reset after the user was requested.
+func (m *meCache) set(user *User, generation int) {
+	m.mu.Lock()
+	if m.generation == generation {
+		m.user = user
+	}
+	m.mu.Unlock()
+}
+
+// updateOption handles changes of the option "my_id"; the option is empty while the user isn't authorized.
+func (m *meCache) updateOption(event string) {
+	var update struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(event), &update); err != nil || update.Name != "my_id" {
+		return
+	}
+	value, err := parseOptionValue(update.Value)
+	if err != nil {
+		return
+	}
+	myID, _ := value.Value.(int64)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.myID = myID
+	if m.user != nil && m.user.ID != myID {
+		m.user = nil
+	}
+}
+
+// updateUser refreshes the cached user.
+func (m *meCache) updateUser(event string) {
+	if user, _ := m.get(); user == nil {
+		return
+	}
+	var update struct {
+		User User `json:"user"`
+	}
+	if err := json.Unmarshal([]byte(event), &update); err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.user != nil && m.user.ID == update.User.ID {
+		m.user = &update.User
+	}
+}
+
+// reset forgets the current user.
+func (m *meCache) reset() {
+	m.mu.Lock()
+	m.user = nil
+	m.myID = 0
+	m.generation++
+	m.mu.Unlock()
+}
+
+// Me returns the current user. The user is requested with getMe once and is cached afterwards. The cached user is
+// updated by updateUser and is forgotten when the authorization state changes, for example after logging out.
+func (c *Client) Me(ctx context.Context) (User, error) {
+	if user, _ := c.me.get(); user != nil {
+		return *user, nil
+	}
+
+	select {
+	case c.me.fetch <- struct{}{}:
+	case <-ctx.Done():
+		return User{}, ctx.Err()
+	}
+	defer func() { <-c.me.fetch }()
+	// the user could be fetched by another call while this call was waiting
+	user, generation := c.me.get()
+	if user != nil {
+		return *user, nil
+	}
+
+	result, err := c.Call(ctx, map[string]interface{}{"@type": "getMe"})
+	if err != nil {
+		return User{}, err
+	}
+	user = new(User)
+	if err := json.Unmarshal(result, user); err != nil {
+		return User{}, err
+	}
+	c.me.set(user, generation)
+	return *user, nil
+}
+
+// MyID returns the identifier of the current user from the option "my_id", which is received after authorization.
+// Zero is returned if the user isn't authorized or the option isn't received yet.
+func (c *Client) MyID() int64 {
+	c.me.mu.Lock()
+	defer c.me.mu.Unlock()
+	return c.me.myID
+}
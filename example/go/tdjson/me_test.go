@@ -0,0 +1,104 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func userObject(id int64, firstName string) map[string]interface{} {
+	return map[string]interface{}{"@type": "user", "id": id, "first_name": firstName,
+		"type": map[string]interface{}{"@type": "userTypeBot"}}
+}
+
+// serveMe makes the backend answer getMe and returns the number of received getMe requests.
+func serveMe(td *fakeBackend, user map[string]interface{}) *int32 {
+	var count int32
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "getMe" {
+			atomic.AddInt32(&count, 1)
+			// the response is delayed, so concurrent calls have to wait for it
+			time.Sleep(10 * time.Millisecond)
+			td.push(clientID, withExtra(user, request))
+		}
+	})
+	return &count
+}
+
+func TestMe(t *testing.T) {
+	client, td := newFakeClient(t)
+	count := serveMe(td, userObject(123, "Bot"))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := client.Me(ctx)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if user.ID != 123 || user.FirstName != "Bot" || string(user.Type) != `{"@type":"userTypeBot"}` {
+				t.Errorf("got user %+v", user)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := client.Me(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(count); n != 1 {
+		t.Errorf("getMe was sent %d times", n)
+	}
+
+	// the cached user is updated
+	td.push(client.clientID, map[string]interface{}{"@type": "updateUser", "user": userObject(5, "Other")})
+	td.push(client.clientID, map[string]interface{}{"@type": "updateUser", "user": userObject(123, "Renamed")})
+	for i := 0; i < 2; i++ {
+		client.Receive(5 * time.Second)
+	}
+	if user, _ := client.Me(ctx); user.FirstName != "Renamed" {
+		t.Errorf("got user %+v", user)
+	}
+
+	// the cache is invalidated by the authorization state change
+	td.push(client.clientID, authorizationStateUpdate("authorizationStateLoggingOut"))
+	client.Receive(5 * time.Second)
+	if _, err := client.Me(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(count); n != 2 {
+		t.Errorf("getMe was sent %d times", n)
+	}
+}
+
+func TestMyID(t *testing.T) {
+	client, td := newFakeClient(t)
+	if id := client.MyID(); id != 0 {
+		t.Errorf("got identifier %d before authorization", id)
+	}
+
+	td.push(client.clientID, optionUpdate("my_id", map[string]interface{}{"@type": "optionValueInteger", "value": "123456789"}))
+	client.Receive(5 * time.Second)
+	if id := client.MyID(); id != 123456789 {
+		t.Errorf("got identifier %d", id)
+	}
+
+	td.push(client.clientID, optionUpdate("my_id", map[string]interface{}{"@type": "optionValueEmpty"}))
+	client.Receive(5 * time.Second)
+	if id := client.MyID(); id != 0 {
+		t.Errorf("got identifier %d after logging out", id)
+	}
+}
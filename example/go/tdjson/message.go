@@ -0,0 +1,152 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// Message is a message. Only the most commonly used fields are decoded.
+type Message struct {
+	ID           int64                `json:"id"`
+	ChatID       int64                `json:"chat_id"`
+	SendingState *MessageSendingState `json:"sending_state"` // nil for sent and received messages
+	IsOutgoing   bool                 `json:"is_outgoing"`
+	Date         int32                `json:"date"`
+	EditDate     int32                `json:"edit_date"`
+	Content      json.RawMessage      `json:"content"`
+}
+
+// MessageSendingState is the sending state of an outgoing message.
+type MessageSendingState struct {
+	Type string `json:"@type"` // messageSendingStatePending or messageSendingStateFailed
+
+	// for messageSendingStateFailed
+	ErrorCode    int32   `json:"error_code"`
+	ErrorMessage string  `json:"error_message"`
+	CanRetry     bool    `json:"can_retry"`
+	RetryAfter   float64 `json:"retry_after"`
+}
+
+// SendMessageAndWait sends a message with the given input_message_content to the chat and waits until it is sent.
+// TDLib returns the message with a temporary identifier immediately, so the message with the permanent
+// identifier is taken from the corresponding updateMessageSendSucceeded.
+//
+// If the message can't be sent, the failed message is returned along with an *Error explaining the reason.
+// If the client is destroyed or closed before the result is known, the pending message is returned along with
+// ErrClientDestroyed or ErrClientClosed.
+func (c *Client) SendMessageAndWait(ctx context.Context, chatID int64, content map[string]interface{}) (Message, error) {
+	// the subscription is created before the message is sent, so the update can't be missed
+	sub := c.subscribeTypes("updateMessageSendSucceeded", "updateMessageSendFailed")
+	defer sub.close()
+
+	result, err := c.Call(ctx, map[string]interface{}{
+		"@type":                 "sendMessage",
+		"chat_id":               chatID,
+		"input_message_content": content,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	var message Message
+	if err := json.Unmarshal(result, &message); err != nil {
+		return Message{}, err
+	}
+	switch state := message.SendingState; {
+	case state == nil:
+		return message, nil
+	case state.Type == "messageSendingStateFailed":
+		return message, &Error{Code: int(state.ErrorCode), Message: state.ErrorMessage}
+	}
+
+	for {
+		event, err := sub.next(ctx)
+		if err != nil {
+			return message, err
+		}
+		var update struct {
+			Type         string  `json:"@type"`
+			Message      Message `json:"message"`
+			OldMessageID int64   `json:"old_message_id"`
+			ErrorCode    int     `json:"error_code"`
+			ErrorMessage string  `json:"error_message"`
+		}
+		if err := json.Unmarshal([]byte(event), &update); err != nil || update.OldMessageID != message.ID ||
+			update.Message.ChatID != chatID {
+			continue
+		}
+		if update.Type == "updateMessageSendFailed" {
+			return update.Message, &Error{Code: update.ErrorCode, Message: update.ErrorMessage}
+		}
+		return update.Message, nil
+	}
+}
+
+// messageNotModified is the error message returned by Telegram if the new content of the message is the same.
+const messageNotModified = "MESSAGE_NOT_MODIFIED"
+
+// EditMessageTextAndWait replaces the text of the message and waits until the new content is confirmed
+// by updateMessageContent. The message with the new content is returned.
+//
+// If the text isn't changed, because it is already the same, the current message is returned without an error.
+// The edit can't be confirmed in this case, because there is no content update.
+func (c *Client) EditMessageTextAndWait(ctx context.Context, chatID int64, messageID int64, text FormattedText) (Message, error) {
+	// the subscription is created before the message is edited, so the updates can't be missed
+	sub := c.subscribeTypes("updateMessageContent", "updateMessageEdited")
+	defer sub.close()
+
+	result, err := c.Call(ctx, map[string]interface{}{
+		"@type":                 "editMessageText",
+		"chat_id":               chatID,
+		"message_id":            messageID,
+		"input_message_content": InputMessageText(text, false),
+	})
+	var tdErr *Error
+	if errors.As(err, &tdErr) && tdErr.Code == 400 && tdErr.Message == messageNotModified {
+		result, err = c.Call(ctx, map[string]interface{}{"@type": "getMessage", "chat_id": chatID, "message_id": messageID})
+		if err != nil {
+			return Message{}, err
+		}
+		var message Message
+		err = json.Unmarshal(result, &message)
+		return message, err
+	}
+	if err != nil {
+		return Message{}, err
+	}
+	var message Message
+	if err := json.Unmarshal(result, &message); err != nil {
+		return Message{}, err
+	}
+
+	for {
+		event, err := sub.next(ctx)
+		if err != nil {
+			return message, err
+		}
+		var update struct {
+			Type       string          `json:"@type"`
+			ChatID     int64           `json:"chat_id"`
+			MessageID  int64           `json:"message_id"`
+			NewContent json.RawMessage `json:"new_content"`
+			EditDate   int32           `json:"edit_date"`
+		}
+		if err := json.Unmarshal([]byte(event), &update); err != nil || update.ChatID != chatID ||
+			update.MessageID != messageID {
+			continue
+		}
+		if update.Type == "updateMessageEdited" {
+			message.EditDate = update.EditDate
+			continue
+		}
+		message.Content = update.NewContent
+		return message, nil
+	}
+}
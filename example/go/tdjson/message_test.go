@@ -0,0 +1,226 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testChatID = -1001234567890123
+
+func messageObject(id int64, chatID int64, sendingState map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"@type":         "message",
+		"id":            id,
+		"chat_id":       chatID,
+		"sending_state": sendingState,
+		"is_outgoing":   true,
+		"content":       map[string]interface{}{"@type": "messageText"},
+	}
+}
+
+func textContent(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"@type": "inputMessageText",
+		"text":  map[string]interface{}{"@type": "formattedText", "text": text},
+	}
+}
+
+func TestSendMessageAndWait(t *testing.T) {
+	client, td := newFakeClient(t)
+	pending := map[string]interface{}{"@type": "messageSendingStatePending"}
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "sendMessage" {
+			return
+		}
+		if request["chat_id"].(float64) != testChatID {
+			t.Errorf("got chat_id %v", request["chat_id"])
+		}
+		td.push(clientID, withExtra(messageObject(-2, testChatID, pending), request))
+		// messages with the same temporary identifier in other chats and other messages of the chat
+		td.push(clientID, map[string]interface{}{"@type": "updateMessageSendSucceeded",
+			"message": messageObject(100, 5, nil), "old_message_id": -2})
+		td.push(clientID, map[string]interface{}{"@type": "updateMessageSendSucceeded",
+			"message": messageObject(101, testChatID, nil), "old_message_id": -1})
+		td.push(clientID, map[string]interface{}{"@type": "updateMessageSendSucceeded",
+			"message": messageObject(102, testChatID, nil), "old_message_id": -2})
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	message, err := client.SendMessageAndWait(ctx, testChatID, textContent("Hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.ID != 102 || message.ChatID != testChatID || message.SendingState != nil {
+		t.Errorf("got message %+v", message)
+	}
+}
+
+func TestSendMessageAndWaitFailed(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "sendMessage" {
+			return
+		}
+		td.push(clientID, withExtra(messageObject(-1, testChatID,
+			map[string]interface{}{"@type": "messageSendingStatePending"}), request))
+		td.push(clientID, map[string]interface{}{"@type": "updateMessageSendFailed",
+			"message": messageObject(-1, testChatID, map[string]interface{}{"@type": "messageSendingStateFailed",
+				"error_code": 400, "error_message": "CHAT_WRITE_FORBIDDEN", "can_retry": false}),
+			"old_message_id": -1, "error_code": 400, "error_message": "CHAT_WRITE_FORBIDDEN"})
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	message, err := client.SendMessageAndWait(ctx, testChatID, textContent("Hello"))
+	var tdErr *Error
+	if !errors.As(err, &tdErr) || tdErr.Code != 400 || tdErr.Message != "CHAT_WRITE_FORBIDDEN" {
+		t.Fatalf("got error %v", err)
+	}
+	if message.ID != -1 || message.SendingState == nil || message.SendingState.Type != "messageSendingStateFailed" {
+		t.Errorf("got message %+v", message)
+	}
+}
+
+func TestSendMessageAndWaitCanceled(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "sendMessage" {
+			td.push(clientID, withExtra(messageObject(-1, testChatID,
+				map[string]interface{}{"@type": "messageSendingStatePending"}), request))
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	message, err := client.SendMessageAndWait(ctx, testChatID, textContent("Hello"))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("got error %v", err)
+	}
+	if message.ID != -1 {
+		t.Errorf("got message %+v", message)
+	}
+}
+
+func TestSendMessageAndWaitDestroyed(t *testing.T) {
+	client, td := newFakeClient(t)
+	sent := make(chan struct{})
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "sendMessage" {
+			td.push(clientID, withExtra(messageObject(-1, testChatID,
+				map[string]interface{}{"@type": "messageSendingStatePending"}), request))
+			close(sent)
+		}
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		message, err := client.SendMessageAndWait(context.Background(), testChatID, textContent("Hello"))
+		if message.ID != -1 {
+			t.Errorf("got message %+v", message)
+		}
+		done <- err
+	}()
+	// the client is destroyed after the pending message is returned by sendMessage
+	<-sent
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		client.calls.mu.Lock()
+		pending := len(client.calls.waiters)
+		client.calls.mu.Unlock()
+		if pending == 0 {
+			break
+		}
+	}
+	client.Destroy()
+	waitError(t, done, ErrClientDestroyed)
+}
+
+func TestEditMessageTextAndWait(t *testing.T) {
+	client, td := newFakeClient(t)
+	newContent := map[string]interface{}{"@type": "messageText",
+		"text": map[string]interface{}{"@type": "formattedText", "text": "Edited", "entities": []interface{}{}}}
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] != "editMessageText" {
+			return
+		}
+		content := request["input_message_content"].(map[string]interface{})
+		if request["chat_id"].(float64) != testChatID || request["message_id"] != float64(5) ||
+			content["@type"] != "inputMessageText" || content["text"].(map[string]interface{})["text"] != "Edited" {
+			t.Errorf("got request %v", request)
+		}
+		td.push(clientID, withExtra(messageObject(5, testChatID, nil), request))
+		// updates of other messages must be ignored
+		td.push(clientID, map[string]interface{}{"@type": "updateMessageContent", "chat_id": testChatID, "message_id": 6,
+			"new_content": map[string]interface{}{"@type": "messageText"}})
+		td.push(clientID, map[string]interface{}{"@type": "updateMessageEdited", "chat_id": testChatID, "message_id": 5,
+			"edit_date": 1600000000})
+		td.push(clientID, map[string]interface{}{"@type": "updateMessageContent", "chat_id": testChatID, "message_id": 5,
+			"new_content": newContent})
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	message, err := client.EditMessageTextAndWait(ctx, testChatID, 5, FormattedText{Text: "Edited"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content interface{}
+	if err := json.Unmarshal(message.Content, &content); err != nil || !reflect.DeepEqual(content, newContent) {
+		t.Errorf("got content %s", message.Content)
+	}
+	if message.ID != 5 || message.EditDate != 1600000000 {
+		t.Errorf("got message %+v", message)
+	}
+}
+
+func TestEditMessageTextAndWaitNotModified(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		switch request["@type"] {
+		case "editMessageText":
+			td.push(clientID, map[string]interface{}{"@type": "error", "code": 400, "message": "MESSAGE_NOT_MODIFIED",
+				"@extra": request["@extra"]})
+		case "getMessage":
+			td.push(clientID, withExtra(messageObject(5, testChatID, nil), request))
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	message, err := client.EditMessageTextAndWait(ctx, testChatID, 5, FormattedText{Text: "Same"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.ID != 5 || message.ChatID != testChatID {
+		t.Errorf("got message %+v", message)
+	}
+}
+
+func TestEditMessageTextAndWaitError(t *testing.T) {
+	client, td := newFakeClient(t)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "editMessageText" {
+			td.push(clientID, map[string]interface{}{"@type": "error", "code": 400, "message": "Message can't be edited",
+				"@extra": request["@extra"]})
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.EditMessageTextAndWait(ctx, testChatID, 5, FormattedText{Text: "Edited"})
+	var tdErr *Error
+	if !errors.As(err, &tdErr) || tdErr.Message != "Message can't be edited" {
+		t.Errorf("got error %v", err)
+	}
+}
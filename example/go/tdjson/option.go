@@ -0,0 +1,105 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// OptionValue is a value of a TDLib option.
+type OptionValue struct {
+	Type string // optionValueBoolean, optionValueInteger, optionValueString or optionValueEmpty
+
+	// a bool for optionValueBoolean, an int64 for optionValueInteger, a string for optionValueString
+	// and nil for optionValueEmpty, which is returned for unknown options and options with a default value
+	Value interface{}
+}
+
+func parseOptionValue(data []byte) (OptionValue, error) {
+	var object struct {
+		Type  string          `json:"@type"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return OptionValue{}, err
+	}
+
+	value := OptionValue{Type: object.Type}
+	var err error
+	switch object.Type {
+	case "optionValueBoolean":
+		var b bool
+		err = json.Unmarshal(object.Value, &b)
+		value.Value = b
+	case "optionValueInteger":
+		// int64 values are serialized as strings
+		var s string
+		if err = json.Unmarshal(object.Value, &s); err == nil {
+			value.Value, err = strconv.ParseInt(s, 10, 64)
+		}
+	case "optionValueString":
+		var s string
+		err = json.Unmarshal(object.Value, &s)
+		value.Value = s
+	case "optionValueEmpty":
+	default:
+		return OptionValue{}, fmt.Errorf("tdjson: unexpected option value %s", data)
+	}
+	if err != nil {
+		return OptionValue{}, fmt.Errorf("tdjson: invalid %s: %v", object.Type, err)
+	}
+	return value, nil
+}
+
+// optionValueObject returns the optionValue object corresponding to a Go value.
+func optionValueObject(value interface{}) (map[string]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return map[string]interface{}{"@type": "optionValueEmpty"}, nil
+	case bool:
+		return map[string]interface{}{"@type": "optionValueBoolean", "value": v}, nil
+	case string:
+		return map[string]interface{}{"@type": "optionValueString", "value": v}, nil
+	case int:
+		return optionValueInteger(int64(v)), nil
+	case int32:
+		return optionValueInteger(int64(v)), nil
+	case int64:
+		return optionValueInteger(v), nil
+	default:
+		return nil, fmt.Errorf("tdjson: unsupported option value type %T", value)
+	}
+}
+
+func optionValueInteger(value int64) map[string]interface{} {
+	return map[string]interface{}{"@type": "optionValueInteger", "value": strconv.FormatInt(value, 10)}
+}
+
+// GetOption returns the value of the option. The list of available options can be found
+// at https://core.telegram.org/tdlib/options.
+func (c *Client) GetOption(ctx context.Context, name string) (OptionValue, error) {
+	result, err := c.Call(ctx, map[string]interface{}{"@type": "getOption", "name": name})
+	if err != nil {
+		return OptionValue{}, err
+	}
+	return parseOptionValue(result)
+}
+
+// SetOption sets the value of a writable option. The value must be a bool, a string, an integer of type int,
+// int32 or int64, or nil to reset the option to its default value.
+func (c *Client) SetOption(ctx context.Context, name string, value interface{}) error {
+	optionValue, err := optionValueObject(value)
+	if err != nil {
+		return err
+	}
+	_, err = c.Call(ctx, map[string]interface{}{"@type": "setOption", "name": name, "value": optionValue})
+	return err
+}
@@ -0,0 +1,107 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// optionBackend stores options set by setOption and returns them for getOption.
+func optionBackend(t *testing.T, options map[string]interface{}) (*Client, <-chan map[string]interface{}) {
+	client, td := newFakeClient(t)
+	requests := make(chan map[string]interface{}, 10)
+	td.respond(func(request map[string]interface{}) map[string]interface{} {
+		switch request["@type"] {
+		case "getOption":
+			if value, ok := options[request["name"].(string)].(map[string]interface{}); ok {
+				return value
+			}
+			return map[string]interface{}{"@type": "optionValueEmpty"}
+		case "setOption":
+			requests <- request
+			options[request["name"].(string)] = request["value"]
+			return map[string]interface{}{"@type": "ok"}
+		}
+		return nil
+	})
+	return client, requests
+}
+
+func TestGetOption(t *testing.T) {
+	client, _ := optionBackend(t, map[string]interface{}{
+		"my_id":               map[string]interface{}{"@type": "optionValueInteger", "value": "9007199254740993"},
+		"version":             map[string]interface{}{"@type": "optionValueString", "value": "1.7.5"},
+		"is_location_visible": map[string]interface{}{"@type": "optionValueBoolean", "value": true},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		name string
+		want OptionValue
+	}{
+		{"my_id", OptionValue{"optionValueInteger", int64(9007199254740993)}},
+		{"version", OptionValue{"optionValueString", "1.7.5"}},
+		{"is_location_visible", OptionValue{"optionValueBoolean", true}},
+		{"unknown_option", OptionValue{"optionValueEmpty", nil}},
+	}
+	for _, test := range tests {
+		value, err := client.GetOption(ctx, test.name)
+		if err != nil {
+			t.Errorf("GetOption(%q) failed: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, test.want) {
+			t.Errorf("GetOption(%q) = %#v, want %#v", test.name, value, test.want)
+		}
+	}
+}
+
+func TestSetOption(t *testing.T) {
+	client, requests := optionBackend(t, make(map[string]interface{}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		value interface{}
+		want  map[string]interface{}
+		read  interface{} // the value returned by GetOption afterwards
+	}{
+		{true, map[string]interface{}{"@type": "optionValueBoolean", "value": true}, true},
+		{"en", map[string]interface{}{"@type": "optionValueString", "value": "en"}, "en"},
+		{42, map[string]interface{}{"@type": "optionValueInteger", "value": "42"}, int64(42)},
+		{int32(-1), map[string]interface{}{"@type": "optionValueInteger", "value": "-1"}, int64(-1)},
+		{int64(9007199254740993), map[string]interface{}{"@type": "optionValueInteger", "value": "9007199254740993"},
+			int64(9007199254740993)},
+		{nil, map[string]interface{}{"@type": "optionValueEmpty"}, nil},
+	}
+	for _, test := range tests {
+		if err := client.SetOption(ctx, "test_option", test.value); err != nil {
+			t.Fatalf("SetOption(%v) failed: %v", test.value, err)
+		}
+		request := <-requests
+		if request["name"] != "test_option" || !reflect.DeepEqual(request["value"], test.want) {
+			t.Errorf("SetOption(%v) sent %v", test.value, request)
+		}
+
+		value, err := client.GetOption(ctx, "test_option")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value.Type != test.want["@type"] || value.Value != test.read {
+			t.Errorf("GetOption returned %#v after SetOption(%v)", value, test.value)
+		}
+	}
+
+	if err := client.SetOption(ctx, "test_option", 1.5); err == nil {
+		t.Error("unsupported value type was accepted")
+	}
+}
@@ -0,0 +1,101 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import "runtime"
+
+// TdlibParameters contains parameters for TDLib initialization. It is serialized to the tdlibParameters object.
+type TdlibParameters struct {
+	UseTestDC              bool   `json:"use_test_dc"`
+	DatabaseDirectory      string `json:"database_directory"`
+	FilesDirectory         string `json:"files_directory"`
+	UseFileDatabase        bool   `json:"use_file_database"`
+	UseChatInfoDatabase    bool   `json:"use_chat_info_database"`
+	UseMessageDatabase     bool   `json:"use_message_database"`
+	UseSecretChats         bool   `json:"use_secret_chats"`
+	APIID                  int32  `json:"api_id"`
+	APIHash                string `json:"api_hash"`
+	SystemLanguageCode     string `json:"system_language_code"`
+	DeviceModel            string `json:"device_model"`
+	SystemVersion          string `json:"system_version"`
+	ApplicationVersion     string `json:"application_version"`
+	EnableStorageOptimizer bool   `json:"enable_storage_optimizer"`
+	IgnoreFileNames        bool   `json:"ignore_file_names"`
+
+	// DatabaseEncryptionKey isn't a field of tdlibParameters. It is passed by Authorizer in checkDatabaseEncryptionKey
+	// to decrypt the local database or to encrypt a new one. The database isn't encrypted if the key is empty.
+	DatabaseEncryptionKey []byte `json:"-"`
+}
+
+// DefaultParameters returns TDLib parameters for the given application identifier and hash obtained
+// at https://my.telegram.org. The databases are stored in the directory "tdlib", and the device and system
+// are described by the platform for which the program is built.
+func DefaultParameters(apiID int32, apiHash string) *TdlibParameters {
+	return &TdlibParameters{
+		DatabaseDirectory:      "tdlib",
+		UseFileDatabase:        true,
+		UseChatInfoDatabase:    true,
+		UseMessageDatabase:     true,
+		APIID:                  apiID,
+		APIHash:                apiHash,
+		SystemLanguageCode:     "en",
+		DeviceModel:            runtime.GOARCH,
+		SystemVersion:          runtime.GOOS,
+		ApplicationVersion:     "1.0",
+		EnableStorageOptimizer: true,
+	}
+}
+
+// WithDatabaseDir sets the directory for the persistent database.
+func (p *TdlibParameters) WithDatabaseDir(dir string) *TdlibParameters {
+	p.DatabaseDirectory = dir
+	return p
+}
+
+// WithFilesDir sets the directory for storing files. The database directory is used if empty.
+func (p *TdlibParameters) WithFilesDir(dir string) *TdlibParameters {
+	p.FilesDirectory = dir
+	return p
+}
+
+// WithDatabaseEncryptionKey sets the key used to encrypt the local database.
+func (p *TdlibParameters) WithDatabaseEncryptionKey(key []byte) *TdlibParameters {
+	p.DatabaseEncryptionKey = key
+	return p
+}
+
+// WithTestDC makes TDLib use the Telegram test environment instead of the production environment.
+func (p *TdlibParameters) WithTestDC() *TdlibParameters {
+	p.UseTestDC = true
+	return p
+}
+
+// WithSecretChats enables support for secret chats.
+func (p *TdlibParameters) WithSecretChats() *TdlibParameters {
+	p.UseSecretChats = true
+	return p
+}
+
+// WithSystemLanguageCode sets IETF language tag of the user's operating system language.
+func (p *TdlibParameters) WithSystemLanguageCode(languageCode string) *TdlibParameters {
+	p.SystemLanguageCode = languageCode
+	return p
+}
+
+// WithDevice sets model of the device and version of the operating system the application is being run on.
+func (p *TdlibParameters) WithDevice(deviceModel string, systemVersion string) *TdlibParameters {
+	p.DeviceModel = deviceModel
+	p.SystemVersion = systemVersion
+	return p
+}
+
+// WithApplicationVersion sets application version.
+func (p *TdlibParameters) WithApplicationVersion(version string) *TdlibParameters {
+	p.ApplicationVersion = version
+	return p
+}
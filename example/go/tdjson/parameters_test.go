@@ -0,0 +1,70 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDefaultParametersFields(t *testing.T) {
+	p := DefaultParameters(94575, "a3406de8d171bb422bb6ddf3bbd800e2").WithDatabaseDir("db").WithFilesDir("files").WithTestDC()
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// fields of tdlibParameters in td_api.tl
+	want := []string{"api_hash", "api_id", "application_version", "database_directory", "device_model",
+		"enable_storage_optimizer", "files_directory", "ignore_file_names", "system_language_code", "system_version",
+		"use_chat_info_database", "use_file_database", "use_message_database", "use_secret_chats", "use_test_dc"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got fields %v, want %v", names, want)
+	}
+	if fields["database_directory"] != "db" || fields["files_directory"] != "files" || fields["use_test_dc"] != true {
+		t.Errorf("builder methods weren't applied: %s", data)
+	}
+
+	data, err = json.Marshal(DefaultParameters(94575, "a3406de8d171bb422bb6ddf3bbd800e2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"use_test_dc":false`) {
+		t.Errorf("production parameters use the test DC: %s", data)
+	}
+}
+
+func TestDefaultParametersParsedByTDLib(t *testing.T) {
+	request, err := json.Marshal(map[string]interface{}{"@type": "setTdlibParameters", "parameters": DefaultParameters(94575, "hash")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the request can't be executed synchronously, but this is checked only after it is successfully parsed
+	_, err = Execute(string(request))
+	var tdErr *Error
+	if !errors.As(err, &tdErr) {
+		t.Fatalf("got %v, want a TDLib error", err)
+	}
+	if strings.HasPrefix(tdErr.Message, "Failed to parse") {
+		t.Errorf("TDLib failed to parse parameters: %s", tdErr.Message)
+	}
+}
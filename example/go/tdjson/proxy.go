@@ -0,0 +1,123 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Proxy represents a proxy object.
+type Proxy struct {
+	ID           int32           `json:"id"`
+	Server       string          `json:"server"`
+	Port         int32           `json:"port"`
+	LastUsedDate int32           `json:"last_used_date"`
+	IsEnabled    bool            `json:"is_enabled"`
+	Type         json.RawMessage `json:"type"`
+}
+
+// AddSocks5Proxy adds a SOCKS5 proxy server. Username and password may be empty.
+func (c *Client) AddSocks5Proxy(ctx context.Context, server string, port int32, enable bool, username string, password string) (*Proxy, error) {
+	return c.addProxy(ctx, server, port, enable, map[string]interface{}{
+		"@type":    "proxyTypeSocks5",
+		"username": username,
+		"password": password,
+	})
+}
+
+// AddHTTPProxy adds an HTTP transparent proxy server. Username and password may be empty.
+// If httpOnly is true, the proxy is used only for HTTP requests, because it doesn't support
+// transparent TCP connections via HTTP CONNECT method.
+func (c *Client) AddHTTPProxy(ctx context.Context, server string, port int32, enable bool, username string, password string, httpOnly bool) (*Proxy, error) {
+	return c.addProxy(ctx, server, port, enable, map[string]interface{}{
+		"@type":     "proxyTypeHttp",
+		"username":  username,
+		"password":  password,
+		"http_only": httpOnly,
+	})
+}
+
+// AddMTProtoProxy adds an MTProto proxy server. The secret can be specified either in hexadecimal encoding
+// or in base64url encoding, as in proxy links.
+func (c *Client) AddMTProtoProxy(ctx context.Context, server string, port int32, enable bool, secret string) (*Proxy, error) {
+	hexSecret, err := normalizeProxySecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	return c.addProxy(ctx, server, port, enable, map[string]interface{}{
+		"@type":  "proxyTypeMtproto",
+		"secret": hexSecret,
+	})
+}
+
+// normalizeProxySecret returns the MTProto proxy secret in hexadecimal encoding expected by TDLib.
+func normalizeProxySecret(secret string) (string, error) {
+	if secret == "" {
+		return "", errors.New("tdjson: MTProto proxy secret is empty")
+	}
+	if _, err := hex.DecodeString(secret); err == nil {
+		return strings.ToLower(secret), nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", errors.New("tdjson: MTProto proxy secret must be in hexadecimal or base64url encoding")
+	}
+	return hex.EncodeToString(data), nil
+}
+
+func (c *Client) addProxy(ctx context.Context, server string, port int32, enable bool, proxyType map[string]interface{}) (*Proxy, error) {
+	result, err := c.Call(ctx, map[string]interface{}{
+		"@type":  "addProxy",
+		"server": server,
+		"port":   port,
+		"enable": enable,
+		"type":   proxyType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var proxy Proxy
+	if err := json.Unmarshal(result, &proxy); err != nil {
+		return nil, err
+	}
+	return &proxy, nil
+}
+
+// EnableProxy enables the proxy. Only one proxy can be enabled at a time.
+func (c *Client) EnableProxy(ctx context.Context, proxyID int32) error {
+	_, err := c.Call(ctx, map[string]interface{}{"@type": "enableProxy", "proxy_id": proxyID})
+	return err
+}
+
+// DisableProxy disables the currently enabled proxy.
+func (c *Client) DisableProxy(ctx context.Context) error {
+	_, err := c.Call(ctx, map[string]interface{}{"@type": "disableProxy"})
+	return err
+}
+
+// PingProxy computes time needed to receive a response from a Telegram server through the proxy.
+// Use 0 as proxy identifier to ping a Telegram server without a proxy.
+func (c *Client) PingProxy(ctx context.Context, proxyID int32) (time.Duration, error) {
+	result, err := c.Call(ctx, map[string]interface{}{"@type": "pingProxy", "proxy_id": proxyID})
+	if err != nil {
+		return 0, err
+	}
+	var seconds struct {
+		Seconds float64 `json:"seconds"`
+	}
+	if err := json.Unmarshal(result, &seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds.Seconds * float64(time.Second)), nil
+}
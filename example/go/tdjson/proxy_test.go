@@ -0,0 +1,120 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// proxyBackend answers proxy requests and returns the last sent proxy request.
+func proxyBackend(t *testing.T) (*Client, func() map[string]interface{}) {
+	client, td := newFakeClient(t)
+	requests := make(chan map[string]interface{}, 10)
+	td.respond(func(request map[string]interface{}) map[string]interface{} {
+		sent := make(map[string]interface{})
+		for key, value := range request {
+			if key != "@extra" {
+				sent[key] = value
+			}
+		}
+		requests <- sent
+		switch request["@type"] {
+		case "addProxy":
+			return map[string]interface{}{
+				"@type": "proxy", "id": 3, "server": request["server"], "port": request["port"],
+				"is_enabled": request["enable"], "type": request["type"],
+			}
+		case "pingProxy":
+			return map[string]interface{}{"@type": "seconds", "seconds": 0.25}
+		default:
+			return map[string]interface{}{"@type": "ok"}
+		}
+	})
+	return client, func() map[string]interface{} {
+		return <-requests
+	}
+}
+
+func TestAddProxy(t *testing.T) {
+	client, lastRequest := proxyBackend(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		add  func() (*Proxy, error)
+		want map[string]interface{}
+	}{
+		{
+			func() (*Proxy, error) { return client.AddSocks5Proxy(ctx, "127.0.0.1", 1080, true, "user", "pass") },
+			map[string]interface{}{"@type": "proxyTypeSocks5", "username": "user", "password": "pass"},
+		},
+		{
+			func() (*Proxy, error) { return client.AddHTTPProxy(ctx, "127.0.0.1", 1080, true, "", "", true) },
+			map[string]interface{}{"@type": "proxyTypeHttp", "username": "", "password": "", "http_only": true},
+		},
+		{
+			func() (*Proxy, error) {
+				return client.AddMTProtoProxy(ctx, "127.0.0.1", 1080, true, "DD0123456789ABCDEF0123456789ABCDEF")
+			},
+			map[string]interface{}{"@type": "proxyTypeMtproto", "secret": "dd0123456789abcdef0123456789abcdef"},
+		},
+		{
+			func() (*Proxy, error) {
+				return client.AddMTProtoProxy(ctx, "127.0.0.1", 1080, true, "3QEjRWeJq83vASNFZ4mrze8")
+			},
+			map[string]interface{}{"@type": "proxyTypeMtproto", "secret": "dd0123456789abcdef0123456789abcdef"},
+		},
+	}
+	for _, test := range tests {
+		proxy, err := test.add()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proxy.ID != 3 || !proxy.IsEnabled {
+			t.Errorf("unexpected proxy %+v", proxy)
+		}
+		request := lastRequest()
+		want := map[string]interface{}{"@type": "addProxy", "server": "127.0.0.1", "port": 1080.0, "enable": true, "type": test.want}
+		if !reflect.DeepEqual(request, want) {
+			t.Errorf("got request %v, want %v", request, want)
+		}
+	}
+
+	if _, err := client.AddMTProtoProxy(ctx, "127.0.0.1", 1080, true, "not a secret"); err == nil {
+		t.Error("invalid secret was accepted")
+	}
+}
+
+func TestProxyManagement(t *testing.T) {
+	client, lastRequest := proxyBackend(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.EnableProxy(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if request := lastRequest(); request["@type"] != "enableProxy" || request["proxy_id"] != 3.0 {
+		t.Errorf("unexpected request %v", request)
+	}
+	if err := client.DisableProxy(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if request := lastRequest(); request["@type"] != "disableProxy" {
+		t.Errorf("unexpected request %v", request)
+	}
+	ping, err := client.PingProxy(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ping != 250*time.Millisecond {
+		t.Errorf("got ping %v, want 250ms", ping)
+	}
+}
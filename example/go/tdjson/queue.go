@@ -0,0 +1,79 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"sync"
+	"time"
+)
+
+// eventQueue is an unbounded FIFO queue of received events.
+// It never blocks the producer, so a slow consumer of one client can't stall the others,
+// but its memory grows while the events aren't consumed.
+type eventQueue struct {
+	mu     sync.Mutex
+	events []string
+	ready  chan struct{} // has a pending value if events isn't empty
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *eventQueue) push(event string) {
+	q.mu.Lock()
+	q.events = append(q.events, event)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop waits up to timeout for an event. The second returned value is false if the timeout expires.
+func (q *eventQueue) pop(timeout time.Duration) (string, bool) {
+	expired := make(chan struct{})
+	timer := time.AfterFunc(timeout, func() { close(expired) })
+	defer timer.Stop()
+	return q.popUntil(expired)
+}
+
+// popUntil waits for an event until stop is closed. Already queued events are returned even after that.
+func (q *eventQueue) popUntil(stop <-chan struct{}) (string, bool) {
+	return q.popUntilEither(stop, nil)
+}
+
+// popUntilEither is like popUntil, but stops waiting when either of the channels is closed.
+func (q *eventQueue) popUntilEither(stop <-chan struct{}, otherStop <-chan struct{}) (string, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.events) > 0 {
+			event := q.events[0]
+			q.events[0] = ""
+			q.events = q.events[1:]
+			if len(q.events) > 0 {
+				select {
+				case q.ready <- struct{}{}:
+				default:
+				}
+			}
+			q.mu.Unlock()
+			return event, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-stop:
+			return "", false
+		case <-otherStop:
+			return "", false
+		}
+	}
+}
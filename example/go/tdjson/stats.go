@@ -0,0 +1,76 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stats is a snapshot of counters of events received by a Client.
+type Stats struct {
+	EventsReceived  uint64            // all received events, including responses to requests
+	UpdatesReceived uint64            // received events with "@type" starting with "update"
+	EventsByType    map[string]uint64 // received events by their "@type"
+	PendingCalls    int               // Call requests waiting for a response
+	LastReceive     time.Time         // time when the last event was received; zero if there were no events
+}
+
+// clientStats is updated from the receive loop.
+type clientStats struct {
+	mu              sync.Mutex
+	eventsReceived  uint64
+	updatesReceived uint64
+	eventsByType    map[string]uint64
+	lastReceive     time.Time
+}
+
+func (s *clientStats) record(typ string) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.eventsByType == nil {
+		s.eventsByType = make(map[string]uint64)
+	}
+	s.eventsReceived++
+	if strings.HasPrefix(typ, "update") {
+		s.updatesReceived++
+	}
+	s.eventsByType[typ]++
+	s.lastReceive = now
+}
+
+// received returns the total number of received events.
+func (s *clientStats) received() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.eventsReceived
+}
+
+// Stats returns the current values of the client counters. A LastReceive, which stays old for a long time while
+// requests are being sent, means that TDLib doesn't respond or events aren't received.
+func (c *Client) Stats() Stats {
+	c.calls.mu.Lock()
+	pendingCalls := len(c.calls.waiters)
+	c.calls.mu.Unlock()
+
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	eventsByType := make(map[string]uint64, len(c.stats.eventsByType))
+	for typ, count := range c.stats.eventsByType {
+		eventsByType[typ] = count
+	}
+	return Stats{
+		EventsReceived:  c.stats.eventsReceived,
+		UpdatesReceived: c.stats.updatesReceived,
+		EventsByType:    eventsByType,
+		PendingCalls:    pendingCalls,
+		LastReceive:     c.stats.lastReceive,
+	}
+}
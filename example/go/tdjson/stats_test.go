@@ -0,0 +1,91 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	client, td := newFakeClient(t)
+	if stats := client.Stats(); stats.EventsReceived != 0 || !stats.LastReceive.IsZero() {
+		t.Errorf("got initial stats %+v", stats)
+	}
+
+	start := time.Now()
+	td.push(1, authorizationStateUpdate("authorizationStateWaitTdlibParameters"))
+	td.push(1, map[string]interface{}{"@type": "updateOption", "name": "version"})
+	td.push(1, map[string]interface{}{"@type": "updateOption", "name": "commit_hash"})
+	td.push(1, map[string]interface{}{"@type": "ok"})
+	for i := 0; i < 4; i++ {
+		if event, err := client.Receive(5 * time.Second); event == "" || err != nil {
+			t.Fatalf("failed to receive an event: %v", err)
+		}
+	}
+
+	stats := client.Stats()
+	if stats.EventsReceived != 4 || stats.UpdatesReceived != 3 {
+		t.Errorf("got %d events and %d updates", stats.EventsReceived, stats.UpdatesReceived)
+	}
+	if stats.EventsByType["updateOption"] != 2 || stats.EventsByType["updateAuthorizationState"] != 1 ||
+		stats.EventsByType["ok"] != 1 {
+		t.Errorf("got counts by type %v", stats.EventsByType)
+	}
+	if stats.LastReceive.Before(start) || stats.LastReceive.After(time.Now()) {
+		t.Errorf("got last receive time %v", stats.LastReceive)
+	}
+
+	// the snapshot must not change afterwards
+	stats.EventsByType["ok"] = 100
+	if client.Stats().EventsByType["ok"] != 1 {
+		t.Error("snapshot shares the map with the client")
+	}
+}
+
+func TestStatsPendingCalls(t *testing.T) {
+	client, td := newFakeClient(t)
+	requests := make(chan map[string]interface{}, 2)
+	td.handleSend(func(clientID int, request map[string]interface{}) {
+		if request["@type"] == "getMe" {
+			requests <- request
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := client.Call(ctx, map[string]interface{}{"@type": "getMe"})
+			done <- err
+		}()
+	}
+	first, second := <-requests, <-requests
+	if pending := client.Stats().PendingCalls; pending != 2 {
+		t.Errorf("got %d pending calls", pending)
+	}
+
+	td.push(1, withExtra(map[string]interface{}{"@type": "user"}, first))
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if pending := client.Stats().PendingCalls; pending != 1 {
+		t.Errorf("got %d pending calls", pending)
+	}
+	td.push(1, withExtra(map[string]interface{}{"@type": "user"}, second))
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	stats := client.Stats()
+	if stats.PendingCalls != 0 || stats.EventsByType["user"] != 2 || stats.UpdatesReceived != 0 {
+		t.Errorf("got stats %+v", stats)
+	}
+}
@@ -0,0 +1,101 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"context"
+	"sync"
+)
+
+// subscription receives copies of events matching a filter in the order in which they were received by the client.
+// The events are still returned by Receive as usual.
+type subscription struct {
+	client *Client
+	filter func(header *eventHeader) bool
+	events *eventQueue
+}
+
+// subscriptions is a set of active subscriptions of a client.
+type subscriptions struct {
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+// subscribe starts copying of events, for which the filter returns true, to the returned subscription.
+// The filter is called from the receive loop and must be fast.
+func (c *Client) subscribe(filter func(header *eventHeader) bool) *subscription {
+	s := &subscription{client: c, filter: filter, events: newEventQueue()}
+
+	c.subs.mu.Lock()
+	if c.subs.subs == nil {
+		c.subs.subs = make(map[*subscription]struct{})
+	}
+	c.subs.subs[s] = struct{}{}
+	c.subs.mu.Unlock()
+	return s
+}
+
+// subscribeTypes starts copying of events with the given "@type" to the returned subscription.
+func (c *Client) subscribeTypes(types ...string) *subscription {
+	return c.subscribe(func(header *eventHeader) bool {
+		for _, typ := range types {
+			if header.Type == typ {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (s *subscriptions) publish(event string, header *eventHeader) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subs {
+		if sub.filter(header) {
+			sub.events.push(event)
+		}
+	}
+}
+
+// next waits for the next event or for cancellation of the context.
+func (s *subscription) next(ctx context.Context) (string, error) {
+	return s.client.nextEvent(ctx, s.events)
+}
+
+// nextEvent waits for the next event in the queue of a subscription or for cancellation of the context.
+// When no more events can be received, because the client is destroyed or closed or its Manager is stopped,
+// it fails with the same error as Call after the already queued events are returned.
+func (c *Client) nextEvent(ctx context.Context, events *eventQueue) (string, error) {
+	if event, ok := events.popUntilEither(ctx.Done(), c.calls.abortedChan()); ok {
+		return event, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return "", c.calls.abortError()
+}
+
+// close stops copying of events to the subscription.
+func (s *subscription) close() {
+	s.client.subs.mu.Lock()
+	delete(s.client.subs.subs, s)
+	s.client.subs.mu.Unlock()
+}
+
+// contextWithDone returns a context, which is canceled when done is closed.
+func contextWithDone(done <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
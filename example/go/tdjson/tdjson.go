@@ -0,0 +1,98 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+// Package tdjson provides Go bindings for the TDLib JSON interface.
+//
+// The package is a thin layer over td/telegram/td_json_client.h, so TDLib must be built and installed
+// before it can be used. If TDLib isn't installed to a standard location, point cgo to it:
+//
+//	CGO_CFLAGS=-I<TDLib install prefix>/include CGO_LDFLAGS=-L<TDLib install prefix>/lib go build
+//
+// If the library can't be found, the build fails with "cannot find -ltdjson", and if it isn't found
+// at runtime, the program fails to start with "error while loading shared libraries: libtdjson.so",
+// in which case the library directory must be added to LD_LIBRARY_PATH or to the system library path.
+// Call SelfTest at startup to check that the loaded library works.
+package tdjson
+
+/*
+#cgo LDFLAGS: -ltdjson
+#include <stdlib.h>
+#include <td/telegram/td_json_client.h>
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+// ErrNoResponse is returned by Execute if td_execute returns no response.
+var ErrNoResponse = errors.New("tdjson: td_execute returned no response")
+
+// Execute synchronously executes a JSON-serialized TDLib request and returns the JSON-serialized response.
+// Only requests documented with "Can be called synchronously" can be executed. May be called from any goroutine.
+// If TDLib returns an error object, the object is returned together with an *Error.
+// If TDLib returns no response at all, ErrNoResponse is returned.
+func Execute(request string) (string, error) {
+	cRequest := C.CString(request)
+	defer C.free(unsafe.Pointer(cRequest))
+
+	// the result is stored by TDLib in a thread-local buffer, which is overwritten by the next td_execute or
+	// td_receive call from the same thread, so no other goroutine must run on the thread until the result is copied
+	runtime.LockOSThread()
+	result := C.GoString(C.td_execute(cRequest))
+	runtime.UnlockOSThread()
+
+	if result == "" {
+		return "", ErrNoResponse
+	}
+	return result, responseError([]byte(result))
+}
+
+// selfTestText is the text parsed by SelfTest and its entities.
+const selfTestText = "@tdlib #test https://core.telegram.org"
+
+var selfTestEntities = []TextEntity{
+	{Offset: 0, Length: 6, Type: TextEntityType{Type: "textEntityTypeMention"}},
+	{Offset: 7, Length: 5, Type: TextEntityType{Type: "textEntityTypeHashtag"}},
+	{Offset: 13, Length: 25, Type: TextEntityType{Type: "textEntityTypeUrl"}},
+}
+
+// SelfTest checks that the linked TDLib works by synchronously parsing entities of a known text.
+// It returns a descriptive error if TDLib returns an unexpected response, for example if the loaded
+// library isn't compatible with the C interface used by the package.
+func SelfTest() error {
+	request := `{"@type":"getTextEntities","text":"` + selfTestText + `"}`
+	result, err := execute(request)
+	if errors.Is(err, ErrNoResponse) {
+		return fmt.Errorf("tdjson: self test failed: td_execute returned no response, libtdjson is incompatible")
+	}
+	if err != nil {
+		return fmt.Errorf("tdjson: self test failed: getTextEntities returned %v", err)
+	}
+
+	var response struct {
+		Type     string       `json:"@type"`
+		Entities []TextEntity `json:"entities"`
+	}
+	if err := json.Unmarshal([]byte(result), &response); err != nil || response.Type != "textEntities" {
+		return fmt.Errorf("tdjson: self test failed: unexpected response %q to getTextEntities", result)
+	}
+	if len(response.Entities) != len(selfTestEntities) {
+		return fmt.Errorf("tdjson: self test failed: found %d entities instead of %d in %q",
+			len(response.Entities), len(selfTestEntities), result)
+	}
+	for i, entity := range response.Entities {
+		if entity != selfTestEntities[i] {
+			return fmt.Errorf("tdjson: self test failed: unexpected entity %d in %q", i, result)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecuteGetTextEntities(t *testing.T) {
+	result, err := Execute(`{"@type":"getTextEntities","text":"@me #tag"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type entity struct {
+		Offset int `json:"offset"`
+		Length int `json:"length"`
+		Type   struct {
+			Type string `json:"@type"`
+		} `json:"type"`
+	}
+	var entities struct {
+		Type     string   `json:"@type"`
+		Entities []entity `json:"entities"`
+	}
+	if err := json.Unmarshal([]byte(result), &entities); err != nil {
+		t.Fatal(err)
+	}
+	if entities.Type != "textEntities" {
+		t.Fatalf("unexpected response %s", result)
+	}
+
+	var got []string
+	for _, e := range entities.Entities {
+		got = append(got, e.Type.Type)
+	}
+	want := []string{"textEntityTypeMention", "textEntityTypeHashtag"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got entities %v, want %v", got, want)
+	}
+	if e := entities.Entities[1]; e.Offset != 4 || e.Length != 4 {
+		t.Errorf("got hashtag at %d with length %d, want 4 and 4", e.Offset, e.Length)
+	}
+}
+
+func TestExecuteError(t *testing.T) {
+	if _, err := Execute(`{"@type":"getTextEntities"`); err == nil {
+		t.Error("expected an error for a malformed request")
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelfTestUnexpectedResponse(t *testing.T) {
+	t.Cleanup(func() { execute = Execute })
+	responses := []string{
+		`{"@type":"ok"}`,
+		`{"@type":"textEntities","entities":[]}`,
+		`{"@type":"textEntities","entities":[{"@type":"textEntity","offset":0,"length":6,"type":{"@type":"textEntityTypeMention"}},` +
+			`{"@type":"textEntity","offset":7,"length":5,"type":{"@type":"textEntityTypeHashtag"}},` +
+			`{"@type":"textEntity","offset":13,"length":24,"type":{"@type":"textEntityTypeUrl"}}]}`,
+	}
+	for _, response := range responses {
+		execute = func(request string) (string, error) {
+			return response, nil
+		}
+		if err := SelfTest(); err == nil {
+			t.Errorf("response %q was accepted", response)
+		}
+	}
+
+	execute = func(request string) (string, error) {
+		result := `{"@type":"error","code":400,"message":"stub"}`
+		return result, responseError([]byte(result))
+	}
+	if err := SelfTest(); err == nil {
+		t.Error("error was accepted")
+	}
+
+	execute = func(request string) (string, error) {
+		return "", ErrNoResponse
+	}
+	if err := SelfTest(); err == nil || !strings.Contains(err.Error(), "libtdjson is incompatible") {
+		t.Errorf("got error %v for no response", err)
+	}
+}
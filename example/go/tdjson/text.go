@@ -0,0 +1,142 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// FormattedText represents a formattedText object, a text with some entities.
+type FormattedText struct {
+	Text     string       `json:"text"`
+	Entities []TextEntity `json:"entities"`
+}
+
+// MarshalJSON serializes the text along with its "@type".
+func (t FormattedText) MarshalJSON() ([]byte, error) {
+	type alias FormattedText
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		alias
+	}{"formattedText", alias(t)})
+}
+
+// TextEntity represents a textEntity object, a part of the text that needs to be formatted in some unusual way.
+// Offset and Length are measured in UTF-16 code units.
+type TextEntity struct {
+	Offset int32          `json:"offset"`
+	Length int32          `json:"length"`
+	Type   TextEntityType `json:"type"`
+}
+
+// MarshalJSON serializes the entity along with its "@type".
+func (e TextEntity) MarshalJSON() ([]byte, error) {
+	type alias TextEntity
+	return json.Marshal(struct {
+		Type string `json:"@type"`
+		alias
+	}{"textEntity", alias(e)})
+}
+
+// TextEntityType represents any of textEntityType* objects. Fields, which aren't used by the type, are empty.
+type TextEntityType struct {
+	Type     string `json:"@type"`
+	Language string `json:"language,omitempty"` // textEntityTypePreCode
+	URL      string `json:"url,omitempty"`      // textEntityTypeTextUrl
+	UserID   int32  `json:"user_id,omitempty"`  // textEntityTypeMentionName
+}
+
+// utf16Length returns the length of the string in UTF-16 code units.
+func utf16Length(s string) int32 {
+	var length int32
+	for _, r := range s {
+		if r >= 0x10000 {
+			length += 2 // a surrogate pair
+		} else {
+			length++
+		}
+	}
+	return length
+}
+
+// TextBuilder builds a FormattedText from parts with different formatting, computing offsets and lengths
+// of the entities in UTF-16 code units, as required by TDLib. The zero value is an empty text.
+type TextBuilder struct {
+	text     strings.Builder
+	length   int32 // length of the text in UTF-16 code units
+	entities []TextEntity
+}
+
+// Plain appends a text without formatting.
+func (b *TextBuilder) Plain(text string) *TextBuilder {
+	b.text.WriteString(text)
+	b.length += utf16Length(text)
+	return b
+}
+
+// Entity appends a text formatted with an entity of the given type. Empty texts are ignored.
+func (b *TextBuilder) Entity(text string, entityType TextEntityType) *TextBuilder {
+	length := utf16Length(text)
+	if length == 0 {
+		return b
+	}
+	b.entities = append(b.entities, TextEntity{Offset: b.length, Length: length, Type: entityType})
+	return b.Plain(text)
+}
+
+// Bold appends a bold text.
+func (b *TextBuilder) Bold(text string) *TextBuilder {
+	return b.Entity(text, TextEntityType{Type: "textEntityTypeBold"})
+}
+
+// Italic appends an italic text.
+func (b *TextBuilder) Italic(text string) *TextBuilder {
+	return b.Entity(text, TextEntityType{Type: "textEntityTypeItalic"})
+}
+
+// Underline appends an underlined text.
+func (b *TextBuilder) Underline(text string) *TextBuilder {
+	return b.Entity(text, TextEntityType{Type: "textEntityTypeUnderline"})
+}
+
+// Strikethrough appends a strikethrough text.
+func (b *TextBuilder) Strikethrough(text string) *TextBuilder {
+	return b.Entity(text, TextEntityType{Type: "textEntityTypeStrikethrough"})
+}
+
+// Code appends a text, which must be formatted as inline code.
+func (b *TextBuilder) Code(text string) *TextBuilder {
+	return b.Entity(text, TextEntityType{Type: "textEntityTypeCode"})
+}
+
+// Pre appends a text, which must be formatted as a block of code. If the language is non-empty,
+// the code is highlighted as a code in the language.
+func (b *TextBuilder) Pre(text string, language string) *TextBuilder {
+	if language == "" {
+		return b.Entity(text, TextEntityType{Type: "textEntityTypePre"})
+	}
+	return b.Entity(text, TextEntityType{Type: "textEntityTypePreCode", Language: language})
+}
+
+// Link appends a text, which is a link to the URL.
+func (b *TextBuilder) Link(text string, url string) *TextBuilder {
+	return b.Entity(text, TextEntityType{Type: "textEntityTypeTextUrl", URL: url})
+}
+
+// Mention appends a text, which is a mention of the user by its identifier.
+func (b *TextBuilder) Mention(text string, userID int32) *TextBuilder {
+	return b.Entity(text, TextEntityType{Type: "textEntityTypeMentionName", UserID: userID})
+}
+
+// FormattedText returns the text built so far.
+func (b *TextBuilder) FormattedText() FormattedText {
+	entities := make([]TextEntity, len(b.entities))
+	copy(entities, b.entities)
+	return FormattedText{Text: b.text.String(), Entities: entities}
+}
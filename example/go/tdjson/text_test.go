@@ -0,0 +1,90 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestUTF16Length(t *testing.T) {
+	tests := map[string]int32{
+		"":      0,
+		"abc":   3,
+		"é":     1, // 2 bytes in UTF-8
+		"日本":    2, // 3 bytes each
+		"👍":     2, // a surrogate pair
+		"𝕏":     2, // U+1D54F
+		"a👍b𝕏c": 7,
+		"👨‍👩‍👧": 8, // 3 surrogate pairs joined by 2 zero-width joiners
+	}
+	for s, expected := range tests {
+		if length := utf16Length(s); length != expected {
+			t.Errorf("utf16Length(%q) = %d, expected %d", s, length, expected)
+		}
+	}
+}
+
+func TestTextBuilder(t *testing.T) {
+	var b TextBuilder
+	text := b.Plain("👍 ").Bold("bold").Plain(" 𝕏 ").Italic("itálic").Plain("\n").
+		Code("").Pre("fmt.Println(\"😀\")", "go").Plain(" ").Link("🔗 link", "https://telegram.org").
+		Plain(" ").Mention("user", 123).FormattedText()
+
+	if expected := "👍 bold 𝕏 itálic\nfmt.Println(\"😀\") 🔗 link user"; text.Text != expected {
+		t.Errorf("got text %q", text.Text)
+	}
+	expected := []TextEntity{
+		{Offset: 3, Length: 4, Type: TextEntityType{Type: "textEntityTypeBold"}},
+		{Offset: 11, Length: 6, Type: TextEntityType{Type: "textEntityTypeItalic"}},
+		{Offset: 18, Length: 17, Type: TextEntityType{Type: "textEntityTypePreCode", Language: "go"}},
+		{Offset: 36, Length: 7, Type: TextEntityType{Type: "textEntityTypeTextUrl", URL: "https://telegram.org"}},
+		{Offset: 44, Length: 4, Type: TextEntityType{Type: "textEntityTypeMentionName", UserID: 123}},
+	}
+	if !reflect.DeepEqual(text.Entities, expected) {
+		t.Errorf("got entities %+v", text.Entities)
+	}
+
+	// the entities must cover exactly the formatted parts of the text in UTF-16
+	units := utf16Units(text.Text)
+	parts := []string{"bold", "itálic", "fmt.Println(\"😀\")", "🔗 link", "user"}
+	for i, entity := range text.Entities {
+		if got := string(decodeUTF16(units[entity.Offset : entity.Offset+entity.Length])); got != parts[i] {
+			t.Errorf("entity %d covers %q, expected %q", i, got, parts[i])
+		}
+	}
+}
+
+func TestTextBuilderJSON(t *testing.T) {
+	var b TextBuilder
+	data, err := json.Marshal(b.Bold("😀").Plain(" ").Pre("x", "").FormattedText())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"@type":"formattedText","text":"😀 x","entities":[` +
+		`{"@type":"textEntity","offset":0,"length":2,"type":{"@type":"textEntityTypeBold"}},` +
+		`{"@type":"textEntity","offset":3,"length":1,"type":{"@type":"textEntityTypePre"}}]}`
+	if string(data) != expected {
+		t.Errorf("got %s", data)
+	}
+
+	var empty TextBuilder
+	if data, _ := json.Marshal(empty.FormattedText()); string(data) != `{"@type":"formattedText","text":"","entities":[]}` {
+		t.Errorf("got %s for the empty text", data)
+	}
+}
+
+func utf16Units(s string) []uint16 {
+	return utf16.Encode([]rune(s))
+}
+
+func decodeUTF16(units []uint16) []rune {
+	return utf16.Decode(units)
+}
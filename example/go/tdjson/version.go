@@ -0,0 +1,53 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+// tdlibVersion and tdlibCommitHash contain the values of the options "version" and "commit_hash",
+// which are the same for all clients, because they describe the linked TDLib.
+var tdlibVersion, tdlibCommitHash atomic.Value
+
+// Version returns the version of the linked TDLib, for example "1.7.5".
+//
+// The version is taken from the updateOption "version", which is one of the first updates sent by every client
+// after it receives the first request, so an empty string is returned until events of some client are received.
+func Version() string {
+	version, _ := tdlibVersion.Load().(string)
+	return version
+}
+
+// CommitHash returns the commit hash of the linked TDLib if it is known. Like the version, it is taken
+// from the corresponding updateOption, so an empty string is returned until events of some client are received.
+func CommitHash() string {
+	commitHash, _ := tdlibCommitHash.Load().(string)
+	return commitHash
+}
+
+// recordVersion remembers the version and the commit hash of TDLib from the updateOption.
+func recordVersion(event string) {
+	var update struct {
+		Name  string `json:"name"`
+		Value struct {
+			Type  string `json:"@type"`
+			Value string `json:"value"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(event), &update); err != nil || update.Value.Type != "optionValueString" {
+		return
+	}
+	switch update.Name {
+	case "version":
+		tdlibVersion.Store(update.Value.Value)
+	case "commit_hash":
+		tdlibCommitHash.Store(update.Value.Value)
+	}
+}
@@ -0,0 +1,44 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"testing"
+	"time"
+)
+
+func optionUpdate(name string, value map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"@type": "updateOption", "name": name, "value": value}
+}
+
+func TestVersion(t *testing.T) {
+	tdlibVersion.Store("")
+	tdlibCommitHash.Store("")
+	if Version() != "" || CommitHash() != "" {
+		t.Fatal("version is known before any event is received")
+	}
+
+	client, td := newFakeClient(t)
+	// the initial updates sent by TDLib after the first request
+	td.push(1, optionUpdate("version", map[string]interface{}{"@type": "optionValueString", "value": "1.7.5"}))
+	td.push(1, optionUpdate("commit_hash", map[string]interface{}{"@type": "optionValueString", "value": "abcdef"}))
+	td.push(1, optionUpdate("utc_time", map[string]interface{}{"@type": "optionValueInteger", "value": "1600000000"}))
+	td.push(1, authorizationStateUpdate("authorizationStateWaitTdlibParameters"))
+	for i := 0; i < 4; i++ {
+		if event, _ := client.Receive(5 * time.Second); event == "" {
+			t.Fatal("event wasn't received")
+		}
+	}
+
+	if version := Version(); version != "1.7.5" {
+		t.Errorf("got version %q", version)
+	}
+	if commitHash := CommitHash(); commitHash != "abcdef" {
+		t.Errorf("got commit hash %q", commitHash)
+	}
+}
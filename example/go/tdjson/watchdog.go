@@ -0,0 +1,86 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"sync"
+	"time"
+)
+
+// clock is the source of time of watchdogs.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// watchdogClock is replaced in tests.
+var watchdogClock clock = realClock{}
+
+// OnStall starts a watchdog, which calls fn if no event was received by the client for longer than threshold
+// while the connection state is ConnectionStateReady. It is a sign that the receive loop or TDLib hangs.
+// The argument of fn is the time passed since the last event or since the connection became ready.
+//
+// Time, during which the connection isn't ready, isn't counted, so the watchdog doesn't fire while the client
+// is offline. The threshold must still exceed the time for which the client can legitimately stay idle online.
+// The callback is called once per stall and is called again only after some event is received.
+// The watchdog is stopped by the returned function or when the client is destroyed or closed.
+func (c *Client) OnStall(threshold time.Duration, fn func(d time.Duration)) (stop func()) {
+	clk := watchdogClock
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// a stall is noticed at most a quarter of the threshold late
+		interval := threshold / 4
+		lastEventCount := c.stats.received()
+		lastEventTime := clk.Now()
+		isStalled := false
+		for {
+			select {
+			case <-clk.After(interval):
+			case <-stopped:
+				return
+			case <-c.done:
+				return
+			case <-c.calls.abortedChan():
+				return
+			}
+
+			now := clk.Now()
+			state, _ := c.connection.get()
+			if count := c.stats.received(); count != lastEventCount || state != ConnectionStateReady {
+				lastEventCount = count
+				lastEventTime = now
+				isStalled = false
+				continue
+			}
+			if d := now.Sub(lastEventTime); d > threshold && !isStalled {
+				isStalled = true
+				fn(d)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopped)
+			<-done
+		})
+	}
+}
@@ -0,0 +1,188 @@
+//
+// Copyright Aliaksei Levin (levlam@telegram.org), Arseny Smirnov (arseny30@gmail.com) 2014-2021
+//
+// Distributed under the Boost Software License, Version 1.0. (See accompanying
+// file LICENSE_1_0.txt or copy at http://www.boost.org/LICENSE_1_0.txt)
+//
+
+package tdjson
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock, which is advanced only by a test.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []fakeTimer
+	waiting chan struct{} // receives a value whenever After is called
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1600000000, 0), waiting: make(chan struct{}, 100)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := fakeTimer{c.now.Add(d), make(chan time.Time, 1)}
+	c.timers = append(c.timers, timer)
+	c.waiting <- struct{}{}
+	return timer.c
+}
+
+// advance moves the time forward and fires expired timers.
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	timers := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			timers = append(timers, timer)
+		} else {
+			timer.c <- c.now
+		}
+	}
+	c.timers = timers
+}
+
+// startWatchdog starts a watchdog with the threshold of 1 minute, which is checked every 15 seconds.
+func startWatchdog(t *testing.T, client *Client) (*fakeClock, <-chan time.Duration) {
+	clk := newFakeClock()
+	defer func(c clock) { watchdogClock = c }(watchdogClock)
+	watchdogClock = clk
+
+	stalls := make(chan time.Duration, 10)
+	stop := client.OnStall(time.Minute, func(d time.Duration) { stalls <- d })
+	t.Cleanup(stop)
+	<-clk.waiting
+	return clk, stalls
+}
+
+// tick advances the clock to the next check of the watchdog and waits until the check is done.
+func tick(clk *fakeClock) {
+	clk.advance(15 * time.Second)
+	<-clk.waiting
+}
+
+// receiveEvent waits until the client receives the pushed event.
+func receiveEvent(t *testing.T, client *Client, td *fakeBackend, event map[string]interface{}) {
+	td.push(client.clientID, event)
+	if event, _ := client.Receive(5 * time.Second); event == "" {
+		t.Fatal("event wasn't received")
+	}
+}
+
+func TestOnStall(t *testing.T) {
+	client, td := newFakeClient(t)
+	receiveEvent(t, client, td, connectionStateUpdate(ConnectionStateReady))
+	clk, stalls := startWatchdog(t, client)
+
+	for i := 0; i < 4; i++ {
+		tick(clk)
+	}
+	if len(stalls) != 0 {
+		t.Fatal("watchdog fired on the threshold")
+	}
+	tick(clk)
+	if len(stalls) != 1 {
+		t.Fatal("watchdog didn't fire after the threshold")
+	}
+	if d := <-stalls; d != 75*time.Second {
+		t.Errorf("got stall duration %v", d)
+	}
+
+	// the stall is reported once
+	for i := 0; i < 8; i++ {
+		tick(clk)
+	}
+	if len(stalls) != 0 {
+		t.Fatal("stall was reported again")
+	}
+
+	// the watchdog is rearmed by an event
+	receiveEvent(t, client, td, map[string]interface{}{"@type": "updateOption"})
+	for i := 0; i < 5; i++ {
+		tick(clk)
+	}
+	if len(stalls) != 0 {
+		t.Fatal("watchdog fired early after an event")
+	}
+	tick(clk)
+	if len(stalls) != 1 {
+		t.Fatal("watchdog didn't fire after the second stall")
+	}
+}
+
+func TestOnStallOffline(t *testing.T) {
+	client, td := newFakeClient(t)
+	receiveEvent(t, client, td, connectionStateUpdate("connectionStateWaitingForNetwork"))
+	clk, stalls := startWatchdog(t, client)
+
+	for i := 0; i < 100; i++ {
+		tick(clk)
+	}
+	if len(stalls) != 0 {
+		t.Fatal("watchdog fired while offline")
+	}
+
+	// time is counted from the moment the connection became ready
+	receiveEvent(t, client, td, connectionStateUpdate(ConnectionStateReady))
+	for i := 0; i < 5; i++ {
+		tick(clk)
+	}
+	if len(stalls) != 0 {
+		t.Fatal("watchdog fired before the threshold")
+	}
+	tick(clk)
+	if len(stalls) != 1 {
+		t.Fatal("watchdog didn't fire after the threshold")
+	}
+}
+
+func TestOnStallStop(t *testing.T) {
+	client, _ := newFakeClient(t)
+	clk := newFakeClock()
+	defer func(c clock) { watchdogClock = c }(watchdogClock)
+	watchdogClock = clk
+
+	client.OnStall(time.Minute, func(time.Duration) {})()
+	stop := client.OnStall(time.Minute, func(time.Duration) {})
+	client.Destroy()
+	// both watchdogs must be stopped, so stop returns
+	stop()
+}
+
+func TestOnStallClientClosed(t *testing.T) {
+	client, td := newFakeClient(t)
+	receiveEvent(t, client, td, connectionStateUpdate(ConnectionStateReady))
+	clk, stalls := startWatchdog(t, client)
+
+	// the last connection state stays ready, but no events are expected after closing
+	receiveEvent(t, client, td, authorizationStateUpdate("authorizationStateClosed"))
+	for i := 0; i < 10; i++ {
+		clk.advance(15 * time.Second)
+		select {
+		case <-clk.waiting:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if len(stalls) != 0 {
+		t.Fatal("watchdog fired after the client was closed")
+	}
+}